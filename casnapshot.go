@@ -0,0 +1,167 @@
+package gsc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrCADebuggingSnapshot = errors.New("cluster-autoscaler reported debugging snapshot error")
+
+// CADebuggingSnapshot is the JSON written by the `/snapshotz` endpoint of the upstream cluster-autoscaler.
+// See https://github.com/kubernetes/autoscaler/blob/master/cluster-autoscaler/debuggingsnapshot/debugging_snapshot.go
+type CADebuggingSnapshot struct {
+	NodeList                      []CAClusterNode          `json:"NodeList"`
+	UnscheduledPodsCanBeScheduled []*corev1.Pod            `json:"UnscheduledPodsCanBeScheduled"`
+	Error                         string                   `json:"Error,omitempty"`
+	StartTimestamp                time.Time                `json:"StartTimestamp"`
+	EndTimestamp                  time.Time                `json:"EndTimestamp"`
+	TemplateNodes                 map[string]CAClusterNode `json:"TemplateNodes"`
+}
+
+// CAClusterNode is a node of a CADebuggingSnapshot together with the pods on it.
+type CAClusterNode struct {
+	Node *corev1.Node `json:"Node"`
+	Pods []CAPod      `json:"Pods"`
+}
+
+// CAPod is a pod of a CAClusterNode. Newer cluster-autoscaler versions wrap the pod into a scheduler framework
+// PodInfo whose pod is under the Pod key, older versions write the pod itself; both are accepted.
+type CAPod struct {
+	*corev1.Pod
+}
+
+func (p *CAPod) UnmarshalJSON(data []byte) error {
+	var wrapped struct {
+		Pod *corev1.Pod `json:"Pod"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return err
+	}
+	if wrapped.Pod != nil {
+		p.Pod = wrapped.Pod
+		return nil
+	}
+	p.Pod = &corev1.Pod{}
+	return json.Unmarshal(data, p.Pod)
+}
+
+// DecodeCADebuggingSnapshot decodes the JSON served by the cluster-autoscaler `/snapshotz` endpoint.
+func DecodeCADebuggingSnapshot(r io.Reader) (snapshot CADebuggingSnapshot, err error) {
+	if err = json.NewDecoder(r).Decode(&snapshot); err != nil {
+		err = fmt.Errorf("cannot decode cluster-autoscaler debugging snapshot: %w", err)
+	}
+	return
+}
+
+// ToClusterSnapshot converts the debugging snapshot into a ClusterSnapshot taken at its StartTimestamp. The nodes
+// and the pods on them become the nodes and pods of the snapshot, and UnscheduledPodsCanBeScheduled are added as
+// unscheduled pods. Pods listed more than once are taken once, identified by UID or, lacking one, by namespace and
+// name. The AutoscalerConfig is partial: it has one node group and NodeTemplate per template node,
+// named by the node group ID CA keyed it with, taking pool and zone from the template node labels. The TargetSize
+// of a node group is the number of nodes resolving to it, while MinSize, MaxSize and CASettings are unknown to CA
+// debugging snapshots and left unset. If CA reported an error, the converted snapshot is returned along with an
+// error wrapping ErrCADebuggingSnapshot.
+func (s CADebuggingSnapshot) ToClusterSnapshot() (ClusterSnapshot, error) {
+	snapshotTime := s.StartTimestamp.UTC()
+	snapshot := ClusterSnapshot{SnapshotTime: snapshotTime}
+	config := AutoscalerConfig{
+		NodeTemplates: make(map[string]NodeTemplate, len(s.TemplateNodes)),
+		NodeGroups:    make(map[string]NodeGroupInfo, len(s.TemplateNodes)),
+	}
+	for ngName, templateNode := range s.TemplateNodes {
+		if templateNode.Node == nil {
+			continue
+		}
+		template := asCANodeTemplate(ngName, templateNode.Node)
+		poolName, _ := GetPoolName(template.Labels)
+		config.NodeTemplates[ngName] = template
+		config.NodeGroups[ngName] = NodeGroupInfo{Name: ngName, PoolName: poolName, Zone: template.Zone}
+	}
+
+	seenPods := make(map[string]bool)
+	isNewPod := func(pod *corev1.Pod) bool {
+		// pods of trimmed snapshots may lack their UID and are then told apart by namespace and name
+		key := string(pod.UID)
+		if key == "" {
+			key = pod.Namespace + "/" + pod.Name
+		}
+		if seenPods[key] {
+			return false
+		}
+		seenPods[key] = true
+		return true
+	}
+	for _, cn := range s.NodeList {
+		if cn.Node == nil {
+			continue
+		}
+		snapshot.Nodes = append(snapshot.Nodes, AsNodeInfo(cn.Node, snapshotTime))
+		for _, p := range cn.Pods {
+			if p.Pod == nil || !isNewPod(p.Pod) {
+				continue
+			}
+			snapshot.Pods = append(snapshot.Pods, AsPodInfo(p.Pod, snapshotTime))
+		}
+	}
+	for _, p := range s.UnscheduledPodsCanBeScheduled {
+		if p == nil || !isNewPod(p) {
+			continue
+		}
+		podInfo := AsPodInfo(p, snapshotTime)
+		if podInfo.NodeName == "" {
+			// CA only lists pods the scheduler marked unschedulable, even if their condition was not captured
+			podInfo.PodScheduleStatus = PodUnscheduled
+			podInfo.Hash = podInfo.GetHash()
+		}
+		snapshot.Pods = append(snapshot.Pods, podInfo)
+	}
+	slices.SortFunc(snapshot.Nodes, func(a, b NodeInfo) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(snapshot.Pods, func(a, b PodInfo) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+
+	resolver := NewNodeGroupResolver(config.NodeGroups, nil, nil)
+	for _, n := range snapshot.Nodes {
+		if ng, ok := resolver.Resolve(n); ok {
+			ng = config.NodeGroups[ng.Name]
+			ng.TargetSize++
+			config.NodeGroups[ng.Name] = ng
+		}
+	}
+	for name, ng := range config.NodeGroups {
+		ng.Hash = ng.GetHash()
+		config.NodeGroups[name] = ng
+	}
+	config.ExistingNodes = slices.Clone(snapshot.Nodes)
+	config.Hash = config.GetHash()
+	snapshot.AutoscalerConfig = config
+	snapshot.Hash = snapshot.GetHash()
+	if s.Error != "" {
+		return snapshot, fmt.Errorf("%w: %s", ErrCADebuggingSnapshot, s.Error)
+	}
+	return snapshot, nil
+}
+
+func asCANodeTemplate(ngName string, node *corev1.Node) NodeTemplate {
+	instanceType, _ := GetLabelValue(node.Labels, []string{corev1.LabelInstanceTypeStable, corev1.LabelInstanceType})
+	region, _ := GetLabelValue(node.Labels, []string{corev1.LabelTopologyRegion, corev1.LabelFailureDomainBetaRegion})
+	zone, _ := GetZone(node.Labels)
+	t := NodeTemplate{
+		Name:         ngName,
+		InstanceType: instanceType,
+		Region:       region,
+		Zone:         zone,
+		Capacity:     node.Status.Capacity,
+		Allocatable:  node.Status.Allocatable,
+		Labels:       node.Labels,
+		Taints:       node.Spec.Taints,
+	}
+	t.Hash = t.GetHash()
+	return t
+}
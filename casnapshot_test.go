@@ -0,0 +1,73 @@
+package gsc
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCADebuggingSnapshotToClusterSnapshot(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+	}{
+		{name: "pods wrapped into scheduler pod infos", fixture: "snapshotz-wrapped.json"},
+		{name: "bare pods", fixture: "snapshotz-bare.json"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatalf("cannot open fixture: %v", err)
+			}
+			defer f.Close()
+			caSnapshot, err := DecodeCADebuggingSnapshot(f)
+			if err != nil {
+				t.Fatalf("cannot decode fixture: %v", err)
+			}
+			snapshot, err := caSnapshot.ToClusterSnapshot()
+			if err != nil {
+				t.Fatalf("cannot convert debugging snapshot: %v", err)
+			}
+			if want := time.Date(2024, 7, 1, 10, 0, 0, 123456789, time.UTC); !snapshot.SnapshotTime.Equal(want) {
+				t.Errorf("expected snapshot time %s, got %s", want, snapshot.SnapshotTime)
+			}
+
+			var pods []string
+			for _, p := range snapshot.Pods {
+				pods = append(pods, p.Namespace+"/"+p.Name)
+			}
+			// web-0 and web-1 lack their UID and web-1 is listed twice
+			if want := []string{"kube-system/coredns-6c9b8bb4c7-x2kqp", "shop/cart-0", "shop/web-0", "shop/web-1"}; !slices.Equal(pods, want) {
+				t.Errorf("expected pods %v, got %v", want, pods)
+			}
+			wantStatuses := []PodScheduleStatus{PodScheduleCommited, PodUnscheduled, PodScheduleCommited, PodScheduleCommited}
+			for i, p := range snapshot.Pods {
+				if i < len(wantStatuses) && p.PodScheduleStatus != wantStatuses[i] {
+					t.Errorf("expected schedule status %d of pod %s/%s, got %d", wantStatuses[i], p.Namespace, p.Name, p.PodScheduleStatus)
+				}
+			}
+			coredns := snapshot.Pods[0]
+			if coredns.UID != "a1f0c9d8-7b6e-4d5c-9a8b-7c6d5e4f3a2b" || coredns.Spec.PriorityClassName != "system-cluster-critical" || coredns.Requests.Cpu().MilliValue() != 50 {
+				t.Errorf("expected pod coredns with UID, priority class and cpu request, got %s", coredns)
+			}
+
+			if len(snapshot.Nodes) != 1 || snapshot.Nodes[0].ProviderID != "aws:///eu-west-1a/i-0a1b2c3d4e5f60718" {
+				t.Fatalf("expected one node with its provider ID, got %v", snapshot.Nodes)
+			}
+			config := snapshot.AutoscalerConfig
+			ng, ok := config.NodeGroups["shoot--dev--aws-a-z1"]
+			if !ok || ng.PoolName != "a" || ng.Zone != "eu-west-1a" || ng.TargetSize != 1 || ng.Hash != ng.GetHash() {
+				t.Errorf("expected node group shoot--dev--aws-a-z1 of pool a in zone eu-west-1a with target size 1, got %v", config.NodeGroups)
+			}
+			if nt := config.NodeTemplates["shoot--dev--aws-a-z1"]; nt.InstanceType != "m5.large" || nt.Region != "eu-west-1" || nt.Allocatable.Cpu().MilliValue() != 1920 {
+				t.Errorf("expected m5.large node template in region eu-west-1, got %v", nt)
+			}
+			if len(config.ExistingNodes) != 1 || config.Hash != config.GetHash() || snapshot.Hash != snapshot.GetHash() {
+				t.Errorf("expected existing node and hashes of config and snapshot to be set, got %v, %q and %q", config.ExistingNodes, config.Hash, snapshot.Hash)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package clientutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	gsc "github.com/elankath/gardener-scaling-common"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SnapshotApplier materializes ClusterSnapshots into a kube-apiserver as virtual nodes and pods. The first Apply
+// creates all objects of the snapshot; every later Apply only reconciles the difference to the previously applied
+// snapshot. Nodes are labelled with gsc.LabelVirtualScaled since no kubelet backs them.
+type SnapshotApplier struct {
+	client     kubernetes.Interface
+	applied    gsc.ClusterSnapshot
+	namespaces sets.Set[string]
+}
+
+// NewSnapshotApplier creates a SnapshotApplier working on the cluster of the given client.
+func NewSnapshotApplier(client kubernetes.Interface) *SnapshotApplier {
+	return &SnapshotApplier{client: client, namespaces: sets.New[string]()}
+}
+
+// Apply reconciles the cluster to the given snapshot in dependency order: priority classes, namespaces and nodes are
+// created or updated first, then pods are deleted, created or bound, and finally removed nodes and priority classes
+// are deleted. Pods scheduled in the snapshot are created bound to their node and pods that got scheduled since
+// the previous snapshot are bound. Pods whose spec changed are recreated since pod specs are mostly immutable. If
+// any step fails, the joined errors are returned and the next Apply reconciles against the last fully applied
+// snapshot again.
+func (a *SnapshotApplier) Apply(ctx context.Context, snapshot gsc.ClusterSnapshot) error {
+	oldPCs := lo.KeyBy(a.applied.PriorityClasses, func(pc gsc.PriorityClassInfo) string { return pc.Name })
+	newPCs := lo.KeyBy(snapshot.PriorityClasses, func(pc gsc.PriorityClassInfo) string { return pc.Name })
+	oldNodes := lo.KeyBy(a.applied.Nodes, func(n gsc.NodeInfo) string { return n.Name })
+	newNodes := lo.KeyBy(snapshot.Nodes, func(n gsc.NodeInfo) string { return n.Name })
+	oldPods := lo.KeyBy(a.applied.Pods, podKey)
+	newPods := lo.KeyBy(snapshot.Pods, podKey)
+
+	var errs []error
+	for name, pc := range newPCs {
+		if old, ok := oldPCs[name]; ok && old.Hash == pc.Hash {
+			continue
+		}
+		errs = append(errs, a.recreatePriorityClass(ctx, pc))
+	}
+	for _, ns := range sets.List(snapshot.GetPodNamspaces()) {
+		errs = append(errs, a.ensureNamespace(ctx, ns))
+	}
+	for name, n := range newNodes {
+		if old, ok := oldNodes[name]; ok && old.Hash == n.Hash {
+			continue
+		}
+		errs = append(errs, a.createOrUpdateNode(ctx, n))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	for key, p := range oldPods {
+		if _, ok := newPods[key]; !ok {
+			errs = append(errs, a.deletePod(ctx, p))
+		}
+	}
+	for key, p := range newPods {
+		old, ok := oldPods[key]
+		switch {
+		case !ok:
+			errs = append(errs, a.createPod(ctx, p))
+		case old.Hash == p.Hash:
+		case isOnlyBound(old, p):
+			errs = append(errs, a.bindPod(ctx, p))
+		default:
+			if err := a.deletePod(ctx, old); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			errs = append(errs, a.createPod(ctx, p))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	for name := range oldNodes {
+		if _, ok := newNodes[name]; !ok {
+			errs = append(errs, ignoreNotFound(a.client.CoreV1().Nodes().Delete(ctx, name, metav1.DeleteOptions{})))
+		}
+	}
+	for name := range oldPCs {
+		if _, ok := newPCs[name]; !ok {
+			errs = append(errs, ignoreNotFound(a.client.SchedulingV1().PriorityClasses().Delete(ctx, name, metav1.DeleteOptions{})))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	a.applied = snapshot
+	return nil
+}
+
+// GetApplied returns the last snapshot that was fully applied.
+func (a *SnapshotApplier) GetApplied() gsc.ClusterSnapshot {
+	return a.applied
+}
+
+// recreatePriorityClass creates the priority class, deleting an existing one first since its value is immutable.
+func (a *SnapshotApplier) recreatePriorityClass(ctx context.Context, pcInfo gsc.PriorityClassInfo) error {
+	pcs := a.client.SchedulingV1().PriorityClasses()
+	if err := ignoreNotFound(pcs.Delete(ctx, pcInfo.Name, metav1.DeleteOptions{})); err != nil {
+		return fmt.Errorf("cannot delete priority class %q: %w", pcInfo.Name, err)
+	}
+	if _, err := pcs.Create(ctx, gsc.AsPriorityClass(pcInfo), metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("cannot create priority class %q: %w", pcInfo.Name, err)
+	}
+	return nil
+}
+
+func (a *SnapshotApplier) ensureNamespace(ctx context.Context, name string) error {
+	if a.namespaces.Has(name) {
+		return nil
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if _, err := a.client.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("cannot create namespace %q: %w", name, err)
+	}
+	a.namespaces.Insert(name)
+	return nil
+}
+
+// createOrUpdateNode creates or updates the node and then sets its capacity and allocatable with a separate patch
+// of the status subresource, since the node strategy of the apiserver drops status changes made through the node
+// itself.
+func (a *SnapshotApplier) createOrUpdateNode(ctx context.Context, nodeInfo gsc.NodeInfo) error {
+	nodes := a.client.CoreV1().Nodes()
+	node := gsc.AsNode(nodeInfo)
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	node.Labels[gsc.LabelVirtualScaled] = "true"
+	_, err := nodes.Create(ctx, node, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		var existing *corev1.Node
+		if existing, err = nodes.Get(ctx, node.Name, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("cannot get node %q: %w", node.Name, err)
+		}
+		existing.Labels, existing.Spec.Taints, existing.Spec.ProviderID = node.Labels, node.Spec.Taints, node.Spec.ProviderID
+		_, err = nodes.Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("cannot create or update node %q: %w", node.Name, err)
+	}
+	patch, err := json.Marshal(map[string]any{
+		"status": map[string]any{
+			"capacity":    node.Status.Capacity,
+			"allocatable": node.Status.Allocatable,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create status patch for node %q: %w", node.Name, err)
+	}
+	if _, err = nodes.Patch(ctx, node.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status"); err != nil {
+		return fmt.Errorf("cannot patch status of node %q: %w", node.Name, err)
+	}
+	return nil
+}
+
+// createPod creates the pod, bound to its node if it is scheduled. An existing pod of the same name is replaced.
+func (a *SnapshotApplier) createPod(ctx context.Context, podInfo gsc.PodInfo) error {
+	pods := a.client.CoreV1().Pods(podInfo.Namespace)
+	pod := gsc.AsPod(podInfo)
+	_, err := pods.Create(ctx, pod, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		if err = a.deletePod(ctx, podInfo); err != nil {
+			return err
+		}
+		_, err = pods.Create(ctx, pod, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("cannot create pod %q: %w", podKey(podInfo), err)
+	}
+	return nil
+}
+
+func (a *SnapshotApplier) bindPod(ctx context.Context, podInfo gsc.PodInfo) error {
+	binding := &corev1.Binding{
+		ObjectMeta: metav1.ObjectMeta{Name: podInfo.Name, Namespace: podInfo.Namespace},
+		Target:     corev1.ObjectReference{Kind: "Node", Name: podInfo.NodeName},
+	}
+	if err := a.client.CoreV1().Pods(podInfo.Namespace).Bind(ctx, binding, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("cannot bind pod %q to node %q: %w", podKey(podInfo), podInfo.NodeName, err)
+	}
+	return nil
+}
+
+func (a *SnapshotApplier) deletePod(ctx context.Context, podInfo gsc.PodInfo) error {
+	var gracePeriod int64
+	err := a.client.CoreV1().Pods(podInfo.Namespace).Delete(ctx, podInfo.Name, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	if err = ignoreNotFound(err); err != nil {
+		return fmt.Errorf("cannot delete pod %q: %w", podKey(podInfo), err)
+	}
+	return nil
+}
+
+// isOnlyBound returns true if the pod only differs from its old version by having been bound to a node.
+func isOnlyBound(old, pod gsc.PodInfo) bool {
+	if old.NodeName != "" || pod.NodeName == "" {
+		return false
+	}
+	old.NodeName, old.NominatedNodeName, old.PodScheduleStatus = pod.NodeName, pod.NominatedNodeName, pod.PodScheduleStatus
+	return old.GetHash() == pod.Hash
+}
+
+func podKey(p gsc.PodInfo) string {
+	return p.Namespace + "/" + p.Name
+}
+
+func ignoreNotFound(err error) error {
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package clientutil
+
+import (
+	"context"
+	gsc "github.com/elankath/gardener-scaling-common"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"testing"
+	"time"
+)
+
+// bindReactor emulates the apiserver binding pods to nodes, which the fake clientset ignores.
+func bindReactor(client *fake.Clientset) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		create, ok := action.(k8stesting.CreateAction)
+		if !ok || action.GetSubresource() != "binding" {
+			return false, nil, nil
+		}
+		binding := create.GetObject().(*corev1.Binding)
+		pod, err := client.Tracker().Get(corev1.SchemeGroupVersion.WithResource("pods"), binding.Namespace, binding.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		pod = pod.DeepCopyObject()
+		pod.(*corev1.Pod).Spec.NodeName = binding.Target.Name
+		return true, nil, client.Tracker().Update(corev1.SchemeGroupVersion.WithResource("pods"), pod, binding.Namespace)
+	}
+}
+
+func newApplierTestSnapshot(snapshotTime time.Time, priority int32, nodeNames []string, boundPods map[string]string) gsc.ClusterSnapshot {
+	snapshot := gsc.ClusterSnapshot{ID: snapshotTime.Format(time.RFC3339), SnapshotTime: snapshotTime}
+	snapshot.PriorityClasses = append(snapshot.PriorityClasses, gsc.AsPriorityClassInfo(&schedulingv1.PriorityClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "high"},
+		Value:      priority,
+	}, snapshotTime))
+	for _, name := range nodeNames {
+		resources := corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		}
+		snapshot.Nodes = append(snapshot.Nodes, gsc.AsNodeInfo(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelHostname: name}},
+			Status:     corev1.NodeStatus{Capacity: resources, Allocatable: resources},
+		}, snapshotTime))
+	}
+	for _, name := range []string{"api", "batch"} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", UID: types.UID("uid-" + name)},
+			Spec: corev1.PodSpec{
+				PriorityClassName: "high",
+				Containers: []corev1.Container{{
+					Name:      name,
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+				}},
+				NodeName: boundPods[name],
+			},
+		}
+		snapshot.Pods = append(snapshot.Pods, gsc.AsPodInfo(pod, snapshotTime))
+	}
+	snapshot.Hash = snapshot.GetHash()
+	return snapshot
+}
+
+func TestSnapshotApplierApply(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "pods", bindReactor(client))
+	applier := NewSnapshotApplier(client)
+	startTime := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	first := newApplierTestSnapshot(startTime, 100, []string{"node-a", "node-b"}, map[string]string{"api": "node-a"})
+	if err := applier.Apply(ctx, first); err != nil {
+		t.Fatalf("cannot apply first snapshot: %v", err)
+	}
+	nodes, err := ListAllNodes(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 nodes after first snapshot, got %d", len(nodes))
+	}
+	batch, err := client.CoreV1().Pods("shop").Get(ctx, "batch", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("cannot get pod shop/batch: %v", err)
+	}
+	if batch.Spec.NodeName != "" {
+		t.Errorf("expected pod shop/batch to be unscheduled, got node %q", batch.Spec.NodeName)
+	}
+
+	second := newApplierTestSnapshot(startTime.Add(time.Minute), 200, []string{"node-a"}, map[string]string{"api": "node-a", "batch": "node-a"})
+	if err = applier.Apply(ctx, second); err != nil {
+		t.Fatalf("cannot apply second snapshot: %v", err)
+	}
+	if applied := applier.GetApplied(); applied.Hash != second.Hash {
+		t.Errorf("expected applied snapshot hash %q, got %q", second.Hash, applied.Hash)
+	}
+
+	nodes, err = ListAllNodes(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].Name != "node-a" {
+		t.Fatalf("expected only node-a after second snapshot, got %d nodes", len(nodes))
+	}
+	node := nodes[0]
+	if node.Labels[gsc.LabelVirtualScaled] != "true" {
+		t.Errorf("expected node-a to carry label %s=true, got labels %v", gsc.LabelVirtualScaled, node.Labels)
+	}
+	for _, resources := range []corev1.ResourceList{node.Status.Capacity, node.Status.Allocatable} {
+		if cpu := resources[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("4")) != 0 {
+			t.Errorf("expected node-a status cpu of 4, got %s", cpu.String())
+		}
+		if memory := resources[corev1.ResourceMemory]; memory.Cmp(resource.MustParse("16Gi")) != 0 {
+			t.Errorf("expected node-a status memory of 16Gi, got %s", memory.String())
+		}
+	}
+
+	pods, err := ListAllPods(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 2 {
+		t.Fatalf("expected 2 pods after second snapshot, got %d", len(pods))
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "node-a" {
+			t.Errorf("expected pod %s/%s to be bound to node-a, got %q", pod.Namespace, pod.Name, pod.Spec.NodeName)
+		}
+	}
+	bindings := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "create" && action.GetSubresource() == "binding" {
+			bindings++
+		}
+	}
+	if bindings != 1 {
+		t.Errorf("expected pod shop/batch to be bound without being recreated, got %d bindings", bindings)
+	}
+
+	pc, err := client.SchedulingV1().PriorityClasses().Get(ctx, "high", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("cannot get priority class high: %v", err)
+	}
+	if pc.Value != 200 {
+		t.Errorf("expected priority class high to be recreated with value 200, got %d", pc.Value)
+	}
+}
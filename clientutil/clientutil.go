@@ -6,81 +6,204 @@ import (
 	gsc "github.com/elankath/gardener-scaling-common"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"time"
 )
 
-func ListAllNodes(ctx context.Context, clientSet *kubernetes.Clientset) ([]corev1.Node, error) {
-	return ListAllNodesWithPageSize(ctx, clientSet, 0)
+func ListAllNodes(ctx context.Context, client kubernetes.Interface) ([]corev1.Node, error) {
+	return ListAllNodesWithPageSize(ctx, client, 0)
 }
 
-func ListAllPods(ctx context.Context, clientSet *kubernetes.Clientset) ([]corev1.Pod, error) {
-	return ListAllPodsWithPageSize(ctx, clientSet, 0)
+func ListAllPods(ctx context.Context, client kubernetes.Interface) ([]corev1.Pod, error) {
+	return ListAllPodsWithPageSize(ctx, client, 0)
 }
 
-func ListAllNodesWithPageSize(ctx context.Context, clientSet *kubernetes.Clientset, pageSize int) ([]corev1.Node, error) {
+// PageLister lists a single page of objects using the given list options.
+type PageLister[L metav1.ListInterface] func(ctx context.Context, opts metav1.ListOptions) (L, error)
+
+// DefaultListBackoff is the backoff used to retry transient errors while listing.
+var DefaultListBackoff = wait.Backoff{
+	Duration: 200 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+	Cap:      10 * time.Second,
+}
+
+// DefaultMaxRelists is the number of times a list is restarted from scratch after the apiserver reports that the
+// continue token has expired.
+const DefaultMaxRelists = 3
+
+// PageProgress is reported to a PageProgressFunc after every page has been fetched.
+type PageProgress struct {
+	ResourceName string
+	// Page is the 1-based number of the page fetched since the last (re)list.
+	Page int
+	// PageItems is the number of items in the fetched page.
+	PageItems int
+	// TotalItems is the number of items accumulated since the last (re)list.
+	TotalItems int
+	// Relists is the number of times the list was restarted due to an expired continue token.
+	Relists int
+}
+
+type PageProgressFunc func(progress PageProgress)
+
+// PagingOptions controls how ListAllWithOptions pages through a list.
+type PagingOptions struct {
+	// PageSize is the maximum number of items per page. Zero or less lists everything in a single call.
+	PageSize int
+	// Backoff is used to retry transient errors. If Backoff.Steps is zero, DefaultListBackoff is used.
+	Backoff wait.Backoff
+	// MaxRelists is the number of full relists done when a continue token expires. Zero means DefaultMaxRelists,
+	// a negative value disables relisting.
+	MaxRelists int
+	// OnPage, if non-nil, is invoked after every successfully fetched page.
+	OnPage PageProgressFunc
+}
+
+// ListAllWithPageSize repeatedly calls lister, following the continue token of each page, and returns the
+// items of all pages obtained through itemsFn. A pageSize of zero or less lists everything in a single call.
+// resourceName is only used in error messages.
+func ListAllWithPageSize[T any, L metav1.ListInterface](ctx context.Context, resourceName string, lister PageLister[L], itemsFn func(L) []T, pageSize int) ([]T, error) {
+	return ListAllWithOptions(ctx, resourceName, lister, itemsFn, PagingOptions{PageSize: pageSize})
+}
+
+// ListAllWithOptions is like ListAllWithPageSize but retries transient errors with bounded exponential backoff,
+// restarts the list from scratch when the apiserver answers 410 Gone for an expired continue token and reports
+// the progress of every page to PagingOptions.OnPage.
+func ListAllWithOptions[T any, L metav1.ListInterface](ctx context.Context, resourceName string, lister PageLister[L], itemsFn func(L) []T, opts PagingOptions) ([]T, error) {
 	// Initialize the list options with a page size
 	var listOptions metav1.ListOptions
-	if pageSize > 0 {
+	if opts.PageSize > 0 {
 		listOptions = metav1.ListOptions{
-			Limit: int64(pageSize), // Set a limit for pagination
+			Limit: int64(opts.PageSize), // Set a limit for pagination
 		}
 	}
-	var allNodes []corev1.Node
+	maxRelists := opts.MaxRelists
+	if maxRelists == 0 {
+		maxRelists = DefaultMaxRelists
+	}
+	var allItems []T
+	var page, relists int
 	for {
-		// List nodes with the current list options
+		// List objects with the current list options
 		if ctx.Err() != nil {
-			return nil, fmt.Errorf("cannot list nodes since context.Err is non-nil: %w", ctx.Err())
+			return nil, fmt.Errorf("cannot list %s since context.Err is non-nil: %w", resourceName, ctx.Err())
+		}
+		list, err := listWithRetry(ctx, lister, listOptions, opts.Backoff)
+		if apierrors.IsResourceExpired(err) && listOptions.Continue != "" && relists < maxRelists {
+			// The continue token expired: discard what we have and relist from the beginning.
+			relists++
+			allItems, page = nil, 0
+			listOptions.Continue = ""
+			continue
 		}
-		nodes, err := clientSet.CoreV1().Nodes().List(ctx, listOptions)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("cannot list %s: %w", resourceName, err)
+		}
+		// Append the current page of items to the allItems slice
+		items := itemsFn(list)
+		allItems = append(allItems, items...)
+		page++
+		if opts.OnPage != nil {
+			opts.OnPage(PageProgress{
+				ResourceName: resourceName,
+				Page:         page,
+				PageItems:    len(items),
+				TotalItems:   len(allItems),
+				Relists:      relists,
+			})
 		}
-		// Append the current page of nodes to the allNodes slice
-		allNodes = append(allNodes, nodes.Items...)
 		// Check if there is another page
-		if nodes.Continue == "" {
+		if list.GetContinue() == "" {
 			break
 		}
 		// Set the continue token for the next request
-		listOptions.Continue = nodes.Continue
+		listOptions.Continue = list.GetContinue()
 	}
-	return allNodes, nil
+	return allItems, nil
 }
 
-func ListAllPodsWithPageSize(ctx context.Context, clientSet *kubernetes.Clientset, pageSize int) ([]corev1.Pod, error) {
-	// Initialize the list options with a page size
-	var listOptions metav1.ListOptions
-	if pageSize > 0 {
-		listOptions = metav1.ListOptions{
-			Limit: int64(pageSize), // Set a limit for pagination
-		}
+func listWithRetry[L metav1.ListInterface](ctx context.Context, lister PageLister[L], listOptions metav1.ListOptions, backoff wait.Backoff) (list L, err error) {
+	if backoff.Steps == 0 {
+		backoff = DefaultListBackoff
 	}
-	var allPods []corev1.Pod
 	for {
-		// List nodes with the current list options
-		if ctx.Err() != nil {
-			return nil, fmt.Errorf("cannot list Pods since context.Err is non-nil: %w", ctx.Err())
+		list, err = lister(ctx, listOptions)
+		if err == nil || !IsTransientError(err) || backoff.Steps <= 1 {
+			return
 		}
-		nodes, err := clientSet.CoreV1().Pods("").List(ctx, listOptions)
-		if err != nil {
-			return nil, err
-		}
-		// Append the current page of nodes to the allPods slice
-		allPods = append(allPods, nodes.Items...)
-		// Check if there is another page
-		if nodes.Continue == "" {
-			break
+		select {
+		case <-ctx.Done():
+			return list, fmt.Errorf("retry aborted since context is done: %w (last error: %w)", ctx.Err(), err)
+		case <-time.After(backoff.Step()):
 		}
-		// Set the continue token for the next request
-		listOptions.Continue = nodes.Continue
 	}
-	return allPods, nil
 }
 
-func GetKubeSystemPodsRequests(ctx context.Context, clientset *kubernetes.Clientset) (corev1.ResourceList, error) {
-	podList, err := clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{})
+// IsTransientError returns true if the given error returned by the apiserver is worth retrying.
+func IsTransientError(err error) bool {
+	return apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err)
+}
+
+func ListAllNodesWithPageSize(ctx context.Context, client kubernetes.Interface, pageSize int) ([]corev1.Node, error) {
+	return ListAllWithPageSize(ctx, "nodes", client.CoreV1().Nodes().List, func(l *corev1.NodeList) []corev1.Node {
+		return l.Items
+	}, pageSize)
+}
+
+func ListAllPodsWithPageSize(ctx context.Context, client kubernetes.Interface, pageSize int) ([]corev1.Pod, error) {
+	return ListAllWithPageSize(ctx, "Pods", client.CoreV1().Pods("").List, func(l *corev1.PodList) []corev1.Pod {
+		return l.Items
+	}, pageSize)
+}
+
+func ListAllEvents(ctx context.Context, client kubernetes.Interface) ([]corev1.Event, error) {
+	return ListAllEventsWithPageSize(ctx, client, 0)
+}
+
+func ListAllEventsWithPageSize(ctx context.Context, client kubernetes.Interface, pageSize int) ([]corev1.Event, error) {
+	return ListAllWithPageSize(ctx, "events", client.CoreV1().Events("").List, func(l *corev1.EventList) []corev1.Event {
+		return l.Items
+	}, pageSize)
+}
+
+func ListAllPriorityClasses(ctx context.Context, client kubernetes.Interface) ([]schedulingv1.PriorityClass, error) {
+	return ListAllPriorityClassesWithPageSize(ctx, client, 0)
+}
+
+func ListAllPriorityClassesWithPageSize(ctx context.Context, client kubernetes.Interface, pageSize int) ([]schedulingv1.PriorityClass, error) {
+	return ListAllWithPageSize(ctx, "priority classes", client.SchedulingV1().PriorityClasses().List, func(l *schedulingv1.PriorityClassList) []schedulingv1.PriorityClass {
+		return l.Items
+	}, pageSize)
+}
+
+func ListAllPodDisruptionBudgets(ctx context.Context, client kubernetes.Interface) ([]policyv1.PodDisruptionBudget, error) {
+	return ListAllPodDisruptionBudgetsWithPageSize(ctx, client, 0)
+}
+
+func ListAllPodDisruptionBudgetsWithPageSize(ctx context.Context, client kubernetes.Interface, pageSize int) ([]policyv1.PodDisruptionBudget, error) {
+	return ListAllWithPageSize(ctx, "pod disruption budgets", client.PolicyV1().PodDisruptionBudgets("").List, func(l *policyv1.PodDisruptionBudgetList) []policyv1.PodDisruptionBudget {
+		return l.Items
+	}, pageSize)
+}
+
+func GetKubeSystemPodsRequests(ctx context.Context, client kubernetes.Interface) (corev1.ResourceList, error) {
+	podList, err := client.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -97,11 +220,9 @@ func GetKubeSystemPodsRequests(ctx context.Context, clientset *kubernetes.Client
 		}
 	}
 
-	pods := podsByNode[nodeWithMostKubeSystemPods]
-	podSpecs := make([]corev1.PodSpec, numPods)
-	for i := 0; i <= numPods; i++ {
-		podSpecs[i] = pods[i].Spec
-	}
+	podSpecs := lo.Map(podsByNode[nodeWithMostKubeSystemPods], func(item corev1.Pod, _ int) corev1.PodSpec {
+		return item.Spec
+	})
 
 	nodeResource := SumResourceRequest(podSpecs)
 
@@ -0,0 +1,355 @@
+package clientutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"slices"
+	"testing"
+	"time"
+)
+
+// pagedLister serves pages in order from a fake clientset list reactor since the fake list action does not carry
+// the limit and continue token. Errors in failures are returned instead of the page for the given call number.
+type pagedLister struct {
+	pages    []runtime.Object
+	failures map[int]error
+	onPage   func(page int)
+	calls    int
+	next     int
+}
+
+func (l *pagedLister) react(_ k8stesting.Action) (bool, runtime.Object, error) {
+	call := l.calls
+	l.calls++
+	if err, ok := l.failures[call]; ok {
+		if apierrors.IsResourceExpired(err) {
+			l.next = 0
+		}
+		return true, nil, err
+	}
+	if l.onPage != nil {
+		l.onPage(l.next)
+	}
+	page := l.pages[l.next]
+	l.next++
+	return true, page, nil
+}
+
+// paginate splits names into pages of pageSize, building every page with newList and a continue token for all
+// but the last page.
+func paginate(names []string, pageSize int, newList func(names []string, listMeta metav1.ListMeta) runtime.Object) []runtime.Object {
+	var pages []runtime.Object
+	for i := 0; i < len(names); i += pageSize {
+		var listMeta metav1.ListMeta
+		if end := i + pageSize; end < len(names) {
+			listMeta.Continue = fmt.Sprintf("continue-%d", end)
+		}
+		pages = append(pages, newList(names[i:min(i+pageSize, len(names))], listMeta))
+	}
+	return pages
+}
+
+func newNodeList(names []string, listMeta metav1.ListMeta) runtime.Object {
+	return &corev1.NodeList{ListMeta: listMeta, Items: lo.Map(names, func(name string, _ int) corev1.Node {
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	})}
+}
+
+func newPodList(names []string, listMeta metav1.ListMeta) runtime.Object {
+	return &corev1.PodList{ListMeta: listMeta, Items: lo.Map(names, func(name string, _ int) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	})}
+}
+
+func newEventList(names []string, listMeta metav1.ListMeta) runtime.Object {
+	return &corev1.EventList{ListMeta: listMeta, Items: lo.Map(names, func(name string, _ int) corev1.Event {
+		return corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	})}
+}
+
+func newPriorityClassList(names []string, listMeta metav1.ListMeta) runtime.Object {
+	return &schedulingv1.PriorityClassList{ListMeta: listMeta, Items: lo.Map(names, func(name string, _ int) schedulingv1.PriorityClass {
+		return schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	})}
+}
+
+func newPDBList(names []string, listMeta metav1.ListMeta) runtime.Object {
+	return &policyv1.PodDisruptionBudgetList{ListMeta: listMeta, Items: lo.Map(names, func(name string, _ int) policyv1.PodDisruptionBudget {
+		return policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	})}
+}
+
+func objectNames[T any](items []T, err error) ([]string, error) {
+	return lo.Map(items, func(item T, _ int) string {
+		return any(&item).(metav1.Object).GetName()
+	}), err
+}
+
+func itemNames(prefix string, count int) []string {
+	return lo.Times(count, func(i int) string { return fmt.Sprintf("%s-%02d", prefix, i) })
+}
+
+func TestListAllWithPageSize(t *testing.T) {
+	const pageSize = 3
+	tests := []struct {
+		resource string
+		newList  func(names []string, listMeta metav1.ListMeta) runtime.Object
+		list     func(ctx context.Context, client kubernetes.Interface) ([]string, error)
+	}{
+		{"nodes", newNodeList, func(ctx context.Context, client kubernetes.Interface) ([]string, error) {
+			return objectNames(ListAllNodesWithPageSize(ctx, client, pageSize))
+		}},
+		{"pods", newPodList, func(ctx context.Context, client kubernetes.Interface) ([]string, error) {
+			return objectNames(ListAllPodsWithPageSize(ctx, client, pageSize))
+		}},
+		{"events", newEventList, func(ctx context.Context, client kubernetes.Interface) ([]string, error) {
+			return objectNames(ListAllEventsWithPageSize(ctx, client, pageSize))
+		}},
+		{"priorityclasses", newPriorityClassList, func(ctx context.Context, client kubernetes.Interface) ([]string, error) {
+			return objectNames(ListAllPriorityClassesWithPageSize(ctx, client, pageSize))
+		}},
+		{"poddisruptionbudgets", newPDBList, func(ctx context.Context, client kubernetes.Interface) ([]string, error) {
+			return objectNames(ListAllPodDisruptionBudgetsWithPageSize(ctx, client, pageSize))
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.resource, func(t *testing.T) {
+			want := itemNames(tc.resource, 8)
+			lister := &pagedLister{pages: paginate(want, pageSize, tc.newList)}
+			client := fake.NewSimpleClientset()
+			client.PrependReactor("list", tc.resource, lister.react)
+
+			got, err := tc.list(context.Background(), client)
+			if err != nil {
+				t.Fatalf("cannot list %s: %v", tc.resource, err)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("listed %v, want %v", got, want)
+			}
+			if lister.calls != len(lister.pages) {
+				t.Errorf("listed %d pages, want %d", lister.calls, len(lister.pages))
+			}
+		})
+	}
+}
+
+func TestListAllWithPageSizeContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lister := &pagedLister{
+		pages: paginate(itemNames("node", 9), 3, newNodeList),
+		onPage: func(page int) {
+			if page == 1 {
+				cancel()
+			}
+		},
+	}
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "nodes", lister.react)
+
+	nodes, err := ListAllNodesWithPageSize(ctx, client, 3)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if nodes != nil {
+		t.Errorf("expected no nodes on cancellation, got %d", len(nodes))
+	}
+	if lister.calls != 2 {
+		t.Errorf("expected listing to stop after 2 pages, listed %d", lister.calls)
+	}
+}
+
+func TestGetKubeSystemPodsRequests(t *testing.T) {
+	newPod := func(name, nodeName, cpu string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceSystem},
+			Spec: corev1.PodSpec{
+				NodeName: nodeName,
+				Containers: []corev1.Container{{
+					Name:      "c",
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}},
+				}},
+			},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		newPod("a-1", "node-a", "100m"),
+		newPod("a-2", "node-a", "200m"),
+		newPod("b-1", "node-b", "1"),
+	)
+
+	requests, err := GetKubeSystemPodsRequests(context.Background(), client)
+	if err != nil {
+		t.Fatalf("cannot get kube-system pod requests: %v", err)
+	}
+	if cpu := requests[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("300m")) != 0 {
+		t.Errorf("expected cpu requests of the node with most kube-system pods to be 300m, got %s", cpu.String())
+	}
+}
+
+func TestListAllWithOptionsRelistsOnExpiredContinue(t *testing.T) {
+	want := itemNames("node", 7)
+	lister := &pagedLister{
+		pages:    paginate(want, 3, newNodeList),
+		failures: map[int]error{1: apierrors.NewResourceExpired("continue token expired")},
+	}
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "nodes", lister.react)
+
+	var progress []PageProgress
+	nodes, err := ListAllWithOptions(context.Background(), "nodes", client.CoreV1().Nodes().List, func(l *corev1.NodeList) []corev1.Node {
+		return l.Items
+	}, PagingOptions{PageSize: 3, OnPage: func(p PageProgress) { progress = append(progress, p) }})
+	if err != nil {
+		t.Fatalf("cannot list nodes: %v", err)
+	}
+	if got, _ := objectNames(nodes, nil); !slices.Equal(got, want) {
+		t.Errorf("listed %v, want %v without duplicates", got, want)
+	}
+	if len(progress) != 4 {
+		t.Fatalf("expected 4 pages reported, got %d: %v", len(progress), progress)
+	}
+	if last := progress[len(progress)-1]; last.Relists != 1 || last.Page != 3 || last.TotalItems != len(want) {
+		t.Errorf("expected final progress with Relists=1, Page=3, TotalItems=%d, got %+v", len(want), last)
+	}
+}
+
+func TestListAllWithOptionsRetriesTooManyRequests(t *testing.T) {
+	backoff := wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 4}
+	tooManyRequests := apierrors.NewTooManyRequests("slow down", 1)
+	newClient := func(lister *pagedLister) kubernetes.Interface {
+		client := fake.NewSimpleClientset()
+		client.PrependReactor("list", "nodes", lister.react)
+		return client
+	}
+	listNodes := func(client kubernetes.Interface) ([]corev1.Node, error) {
+		return ListAllWithOptions(context.Background(), "nodes", client.CoreV1().Nodes().List, func(l *corev1.NodeList) []corev1.Node {
+			return l.Items
+		}, PagingOptions{PageSize: 3, Backoff: backoff})
+	}
+
+	t.Run("recovers", func(t *testing.T) {
+		lister := &pagedLister{
+			pages:    paginate(itemNames("node", 5), 3, newNodeList),
+			failures: map[int]error{1: tooManyRequests, 2: tooManyRequests},
+		}
+		nodes, err := listNodes(newClient(lister))
+		if err != nil {
+			t.Fatalf("cannot list nodes: %v", err)
+		}
+		if len(nodes) != 5 {
+			t.Errorf("expected 5 nodes, got %d", len(nodes))
+		}
+	})
+	t.Run("gives up", func(t *testing.T) {
+		failures := make(map[int]error)
+		for i := range 10 {
+			failures[i] = tooManyRequests
+		}
+		lister := &pagedLister{pages: paginate(itemNames("node", 5), 3, newNodeList), failures: failures}
+		_, err := listNodes(newClient(lister))
+		if !apierrors.IsTooManyRequests(err) {
+			t.Fatalf("expected final TooManyRequests error, got %v", err)
+		}
+		if lister.calls != backoff.Steps {
+			t.Errorf("expected %d attempts, got %d", backoff.Steps, lister.calls)
+		}
+	})
+}
+
+func newListFilterTestPod(namespace, name, app, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": app}},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func TestListPods(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newListFilterTestPod("shop", "web-1", "web", "node-a"),
+		newListFilterTestPod("shop", "web-2", "web", ""),
+		newListFilterTestPod("shop", "db-1", "db", "node-a"),
+		newListFilterTestPod("blog", "web-1", "web", ""),
+		newListFilterTestPod("kube-system", "web-1", "web", ""),
+	)
+	unscheduled := func(p corev1.Pod) bool { return p.Spec.NodeName == "" }
+	tests := []struct {
+		name   string
+		filter ListFilter[corev1.Pod]
+		want   []string
+	}{
+		{"all", ListFilter[corev1.Pod]{}, []string{"blog/web-1", "kube-system/web-1", "shop/db-1", "shop/web-1", "shop/web-2"}},
+		{"namespaces", ListFilter[corev1.Pod]{Namespaces: []string{"shop", "blog", "shop"}}, []string{"blog/web-1", "shop/db-1", "shop/web-1", "shop/web-2"}},
+		{"label selector", ListFilter[corev1.Pod]{Namespaces: []string{"shop"}, LabelSelector: "app=web"}, []string{"shop/web-1", "shop/web-2"}},
+		{"predicate", ListFilter[corev1.Pod]{LabelSelector: "app=web", Predicates: []func(corev1.Pod) bool{unscheduled}}, []string{"blog/web-1", "kube-system/web-1", "shop/web-2"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pods, err := ListPods(context.Background(), client, tc.filter)
+			if err != nil {
+				t.Fatalf("cannot list pods: %v", err)
+			}
+			got := make([]string, 0, len(pods))
+			for _, p := range pods {
+				got = append(got, p.Namespace+"/"+p.Name)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expected pods %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestListPodsPassesSelectors(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	filter := ListFilter[corev1.Pod]{LabelSelector: "app=web", FieldSelector: UnscheduledPodsFieldSelector}
+	if _, err := ListPods(context.Background(), client, filter); err != nil {
+		t.Fatalf("cannot list pods: %v", err)
+	}
+	actions := client.Actions()
+	if len(actions) != 1 {
+		t.Fatalf("expected a single list action, got %d", len(actions))
+	}
+	restrictions := actions[0].(k8stesting.ListAction).GetListRestrictions()
+	if got := restrictions.Labels.String(); got != filter.LabelSelector {
+		t.Errorf("expected label selector %q, got %q", filter.LabelSelector, got)
+	}
+	if got := restrictions.Fields.String(); got != filter.FieldSelector {
+		t.Errorf("expected field selector %q, got %q", filter.FieldSelector, got)
+	}
+}
+
+func TestListNodesWithPredicateAcrossPages(t *testing.T) {
+	names := itemNames("node", 7)
+	lister := &pagedLister{pages: paginate(names, 3, newNodeList)}
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "nodes", lister.react)
+	filter := ListFilter[corev1.Node]{
+		Predicates: []func(corev1.Node) bool{func(n corev1.Node) bool { return n.Name != "node-01" && n.Name != "node-05" }},
+		Paging:     PagingOptions{PageSize: 3},
+	}
+
+	nodes, err := ListNodes(context.Background(), client, filter)
+	if err != nil {
+		t.Fatalf("cannot list nodes: %v", err)
+	}
+	got, _ := objectNames(nodes, nil)
+	want := []string{"node-00", "node-02", "node-03", "node-04", "node-06"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected nodes %v, got %v", want, got)
+	}
+}
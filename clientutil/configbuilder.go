@@ -0,0 +1,115 @@
+package clientutil
+
+import (
+	"errors"
+	"fmt"
+	gsc "github.com/elankath/gardener-scaling-common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"slices"
+	"strings"
+)
+
+// AutoscalerConfigBuilder assembles a complete AutoscalerConfig from the worker pools of a shoot, the MCM
+// MachineDeployments and MachineClasses of its control plane, its nodes and the cluster-autoscaler settings.
+type AutoscalerConfigBuilder struct {
+	WorkerPools        []gsc.WorkerPoolInfo
+	MachineDeployments []gsc.MachineDeploymentInfo
+	MachineClasses     []unstructured.Unstructured
+	Nodes              []gsc.NodeInfo
+	CASettings         gsc.CASettingsInfo
+	// KubeReserved is passed to AsNodeTemplate when computing the allocatable of node templates.
+	KubeReserved corev1.ResourceList
+	Mode         gsc.AutoscalerMode
+}
+
+// AutoscalerConfigBuildResult is the outcome of AutoscalerConfigBuilder.Build.
+type AutoscalerConfigBuildResult struct {
+	Config gsc.AutoscalerConfig
+	// OrphanedNodes are the nodes that could not be assigned to any node group.
+	OrphanedNodes []gsc.NodeInfo
+	// Mismatches are the differences between the expected node groups and the observed machine deployments.
+	Mismatches []gsc.NodeGroupMismatch
+}
+
+// Build expands the worker pools into node groups, taking min and max sizes from CASettings.NodeGroupsMinMax when
+// present, links every node group to a NodeTemplate derived from the MachineClass of its MachineDeployment,
+// assigns the nodes that are not being deleted to node groups by their pool and zone labels, sets the TargetSize
+// of every node group to the number of its nodes and fills all Hash fields. It fails if a node group has no
+// MachineDeployment or MachineClass.
+func (b AutoscalerConfigBuilder) Build() (result AutoscalerConfigBuildResult, err error) {
+	config := gsc.AutoscalerConfig{
+		NodeTemplates: make(map[string]gsc.NodeTemplate),
+		NodeGroups:    make(map[string]gsc.NodeGroupInfo),
+		CASettings:    b.CASettings,
+		Mode:          b.Mode,
+	}
+	mcdsByName := make(map[string]gsc.MachineDeploymentInfo, len(b.MachineDeployments))
+	for _, mcd := range b.MachineDeployments {
+		mcdsByName[mcd.Name] = mcd
+	}
+	machineClassesByName := make(map[string]*unstructured.Unstructured, len(b.MachineClasses))
+	for i := range b.MachineClasses {
+		machineClassesByName[b.MachineClasses[i].GetName()] = &b.MachineClasses[i]
+	}
+
+	var expectedNodeGroups []gsc.NodeGroupInfo
+	var errs []error
+	for _, pool := range b.WorkerPools {
+		for _, ng := range pool.ToNodeGroups() {
+			if minMax, ok := b.CASettings.NodeGroupsMinMax[ng.Name]; ok {
+				ng.MinSize, ng.MaxSize = minMax.Min, minMax.Max
+			}
+			expectedNodeGroups = append(expectedNodeGroups, ng)
+			config.NodeGroups[ng.Name] = ng
+			mcd, ok := mcdsByName[ng.Name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("no machine deployment for node group %q", ng.Name))
+				continue
+			}
+			machineClass, ok := machineClassesByName[mcd.MachineClassName]
+			if !ok {
+				errs = append(errs, fmt.Errorf("no machine class %q for node group %q", mcd.MachineClassName, ng.Name))
+				continue
+			}
+			nt, err := AsNodeTemplate(machineClass, mcd, b.KubeReserved)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot create node template for node group %q: %w", ng.Name, err))
+				continue
+			}
+			config.NodeTemplates[ng.Name] = nt
+		}
+	}
+	if err = errors.Join(errs...); err != nil {
+		return
+	}
+	result.Mismatches = gsc.CheckNodeGroups(expectedNodeGroups, b.MachineDeployments)
+
+	resolver := gsc.NewNodeGroupResolver(config.NodeGroups, nil, nil)
+	targetSizes := make(map[string]int, len(config.NodeGroups))
+	for _, node := range b.Nodes {
+		if !node.DeletionTimestamp.IsZero() {
+			continue
+		}
+		ng, ok := resolver.Resolve(node)
+		if !ok {
+			result.OrphanedNodes = append(result.OrphanedNodes, node)
+			continue
+		}
+		targetSizes[ng.Name]++
+		node.Hash = node.GetHash()
+		config.ExistingNodes = append(config.ExistingNodes, node)
+	}
+	for name, ng := range config.NodeGroups {
+		ng.TargetSize = targetSizes[name]
+		ng.Hash = ng.GetHash()
+		config.NodeGroups[name] = ng
+	}
+	slices.SortFunc(config.ExistingNodes, func(a, b gsc.NodeInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	config.CASettings.Hash = config.CASettings.GetHash()
+	config.Hash = config.GetHash()
+	result.Config = config
+	return
+}
@@ -0,0 +1,144 @@
+package clientutil
+
+import (
+	gsc "github.com/elankath/gardener-scaling-common"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newConfigBuilderTestNode(name, poolName string, deleted bool) gsc.NodeInfo {
+	node := gsc.NodeInfo{
+		SnapshotMeta: gsc.SnapshotMeta{Name: name},
+		Labels: map[string]string{
+			gsc.PoolLabel:            poolName,
+			corev1.LabelTopologyZone: "eu-west-1a",
+		},
+	}
+	if deleted {
+		node.DeletionTimestamp = time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	}
+	return node
+}
+
+func nodeNames(nodes []gsc.NodeInfo) string {
+	return strings.Join(lo.Map(nodes, func(n gsc.NodeInfo, _ int) string { return n.Name }), ",")
+}
+
+func newConfigBuilder(t *testing.T) AutoscalerConfigBuilder {
+	t.Helper()
+	mcdInfo, err := AsMachineDeploymentInfo(loadUnstructured(t, "machinedeployment.yaml"), time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("cannot convert machine deployment: %v", err)
+	}
+	return AutoscalerConfigBuilder{
+		WorkerPools: []gsc.WorkerPoolInfo{{
+			SnapshotMeta: gsc.SnapshotMeta{Name: "a", Namespace: "shoot--garden--aws"},
+			Minimum:      1,
+			Maximum:      3,
+			Zones:        []string{"eu-west-1a"},
+		}},
+		MachineDeployments: []gsc.MachineDeploymentInfo{mcdInfo},
+		MachineClasses:     []unstructured.Unstructured{*loadUnstructured(t, "machineclass-tiny.yaml")},
+		Nodes: []gsc.NodeInfo{
+			newConfigBuilderTestNode("node-c", "a", false),
+			newConfigBuilderTestNode("node-b", "b", false),
+			newConfigBuilderTestNode("node-a", "a", false),
+			newConfigBuilderTestNode("node-d", "a", true),
+		},
+		CASettings: gsc.CASettingsInfo{
+			Expander:         gsc.DefaultExpander,
+			NodeGroupsMinMax: map[string]gsc.MinMax{"shoot--garden--aws-a-z1": {Min: 2, Max: 5}},
+		},
+		Mode: gsc.AutoscalerReplayerPauseMode,
+	}
+}
+
+func TestAutoscalerConfigBuilderBuild(t *testing.T) {
+	builder := newConfigBuilder(t)
+	unexpected := builder.MachineDeployments[0]
+	unexpected.Name = "shoot--garden--aws-b-z1"
+	builder.MachineDeployments = append(builder.MachineDeployments, unexpected)
+
+	result, err := builder.Build()
+	if err != nil {
+		t.Fatalf("cannot build autoscaler config: %v", err)
+	}
+	config := result.Config
+	ng, ok := config.NodeGroups["shoot--garden--aws-a-z1"]
+	if !ok || len(config.NodeGroups) != 1 {
+		t.Fatalf("expected only node group shoot--garden--aws-a-z1, got %v", config.NodeGroups)
+	}
+	if ng.MinSize != 2 || ng.MaxSize != 5 || ng.TargetSize != 2 {
+		t.Errorf("expected node group with min 2, max 5 and target size 2, got %s", ng)
+	}
+	if ng.Hash == "" || ng.Hash != ng.GetHash() {
+		t.Errorf("expected hash of node group to match its target size, got %q", ng.Hash)
+	}
+	nt, ok := config.NodeTemplates[ng.Name]
+	if !ok || nt.InstanceType != "t4g.nano" || nt.Zone != "eu-west-1a" {
+		t.Errorf("expected t4g.nano node template in zone eu-west-1a, got %v", config.NodeTemplates)
+	}
+	if names := nodeNames(config.ExistingNodes); names != "node-a,node-c" {
+		t.Errorf("expected existing nodes node-a,node-c, got %s", names)
+	}
+	for _, n := range config.ExistingNodes {
+		if n.Hash == "" {
+			t.Errorf("expected hash of existing node %q to be set", n.Name)
+		}
+	}
+	if names := nodeNames(result.OrphanedNodes); names != "node-b" {
+		t.Errorf("expected orphaned node node-b, got %s", names)
+	}
+	if len(result.Mismatches) != 1 || result.Mismatches[0].NodeGroupName != unexpected.Name || result.Mismatches[0].Reason != gsc.MismatchUnexpectedMachineDeployment {
+		t.Errorf("expected unexpected machine deployment %q, got %v", unexpected.Name, result.Mismatches)
+	}
+	if config.Mode != gsc.AutoscalerReplayerPauseMode || config.CASettings.Expander != gsc.DefaultExpander {
+		t.Errorf("expected mode and settings to be taken over, got mode %q and expander %q", config.Mode, config.CASettings.Expander)
+	}
+	if config.CASettings.Hash == "" || config.Hash == "" || config.Hash != config.GetHash() {
+		t.Errorf("expected hashes of settings and config to be set, got %q and %q", config.CASettings.Hash, config.Hash)
+	}
+}
+
+func TestAutoscalerConfigBuilderBuildFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(b *AutoscalerConfigBuilder)
+		wantErr string
+	}{
+		{
+			name: "missing machine deployment",
+			mutate: func(b *AutoscalerConfigBuilder) {
+				b.WorkerPools[0].Zones = append(b.WorkerPools[0].Zones, "eu-west-1b")
+			},
+			wantErr: `no machine deployment for node group "shoot--garden--aws-a-z2"`,
+		},
+		{
+			name: "missing machine class",
+			mutate: func(b *AutoscalerConfigBuilder) {
+				b.MachineClasses = nil
+			},
+			wantErr: `no machine class "shoot--garden--aws-a-z1-8ab1c" for node group "shoot--garden--aws-a-z1"`,
+		},
+		{
+			name: "machine class without node template",
+			mutate: func(b *AutoscalerConfigBuilder) {
+				unstructured.RemoveNestedField(b.MachineClasses[0].Object, "nodeTemplate")
+			},
+			wantErr: `cannot create node template for node group "shoot--garden--aws-a-z1"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := newConfigBuilder(t)
+			tc.mutate(&builder)
+			if _, err := builder.Build(); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
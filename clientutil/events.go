@@ -0,0 +1,50 @@
+package clientutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	gsc "github.com/elankath/gardener-scaling-common"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"slices"
+)
+
+// ListEventInfosWithReasons lists the events in all namespaces having one of the given reasons and returns them as
+// EventInfos sorted by gsc.CompareEventsByEventTime. Each reason is selected by the apiserver via a field selector.
+func ListEventInfosWithReasons(ctx context.Context, client kubernetes.Interface, reasons ...string) ([]gsc.EventInfo, error) {
+	var eventInfos []gsc.EventInfo
+	for _, reason := range lo.Uniq(reasons) {
+		events, err := ListEvents(ctx, client, ListFilter[corev1.Event]{FieldSelector: "reason=" + reason})
+		if err != nil {
+			return nil, fmt.Errorf("cannot list events with reason %q: %w", reason, err)
+		}
+		for _, e := range events {
+			eventInfos = append(eventInfos, gsc.AsEventInfo(&e))
+		}
+	}
+	slices.SortFunc(eventInfos, gsc.CompareEventsByEventTime)
+	return eventInfos, nil
+}
+
+// CollectParsedEvents lists the cluster-autoscaler and scheduler events having one of the gsc.ParsableEventReasons
+// and parses them using gsc.ParseEvent. Events whose message cannot be parsed are left out of the result and
+// their errors are joined into the returned error, so callers may still use the parsed events.
+func CollectParsedEvents(ctx context.Context, client kubernetes.Interface) ([]gsc.ParsedEvent, error) {
+	eventInfos, err := ListEventInfosWithReasons(ctx, client, gsc.ParsableEventReasons...)
+	if err != nil {
+		return nil, err
+	}
+	var parsedEvents []gsc.ParsedEvent
+	var errs []error
+	for _, e := range eventInfos {
+		parsed, err := gsc.ParseEvent(e)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		parsedEvents = append(parsedEvents, parsed)
+	}
+	return parsedEvents, errors.Join(errs...)
+}
@@ -0,0 +1,112 @@
+package clientutil
+
+import (
+	"context"
+	"fmt"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// UnscheduledPodsFieldSelector selects pods that have not yet been bound to a node.
+const UnscheduledPodsFieldSelector = "spec.nodeName="
+
+// NonTerminalPodsFieldSelector selects pods that are neither succeeded nor failed.
+const NonTerminalPodsFieldSelector = "status.phase!=" + string(corev1.PodSucceeded) + ",status.phase!=" + string(corev1.PodFailed)
+
+// ListFilter narrows down the objects returned by the List* functions. Namespaces, LabelSelector and
+// FieldSelector are evaluated by the apiserver, Predicates are evaluated client-side after every page.
+type ListFilter[T any] struct {
+	// Namespaces to list from. Empty means all namespaces. Ignored for cluster-scoped resources.
+	Namespaces []string
+	// LabelSelector in the format accepted by metav1.ListOptions.LabelSelector.
+	LabelSelector string
+	// FieldSelector in the format accepted by metav1.ListOptions.FieldSelector.
+	FieldSelector string
+	// Predicates that an object must all satisfy to be returned.
+	Predicates []func(T) bool
+	Paging     PagingOptions
+}
+
+// Matches returns true if obj satisfies all predicates of the filter.
+func (f ListFilter[T]) Matches(obj T) bool {
+	for _, p := range f.Predicates {
+		if !p(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+func ListPods(ctx context.Context, client kubernetes.Interface, filter ListFilter[corev1.Pod]) ([]corev1.Pod, error) {
+	return listNamespaced(ctx, "pods", filter, func(ns string) PageLister[*corev1.PodList] {
+		return client.CoreV1().Pods(ns).List
+	}, func(l *corev1.PodList) []corev1.Pod {
+		return l.Items
+	})
+}
+
+func ListNodes(ctx context.Context, client kubernetes.Interface, filter ListFilter[corev1.Node]) ([]corev1.Node, error) {
+	return listFiltered(ctx, "nodes", filter, client.CoreV1().Nodes().List, func(l *corev1.NodeList) []corev1.Node {
+		return l.Items
+	})
+}
+
+func ListEvents(ctx context.Context, client kubernetes.Interface, filter ListFilter[corev1.Event]) ([]corev1.Event, error) {
+	return listNamespaced(ctx, "events", filter, func(ns string) PageLister[*corev1.EventList] {
+		return client.CoreV1().Events(ns).List
+	}, func(l *corev1.EventList) []corev1.Event {
+		return l.Items
+	})
+}
+
+func ListPriorityClasses(ctx context.Context, client kubernetes.Interface, filter ListFilter[schedulingv1.PriorityClass]) ([]schedulingv1.PriorityClass, error) {
+	return listFiltered(ctx, "priority classes", filter, client.SchedulingV1().PriorityClasses().List, func(l *schedulingv1.PriorityClassList) []schedulingv1.PriorityClass {
+		return l.Items
+	})
+}
+
+func ListPodDisruptionBudgets(ctx context.Context, client kubernetes.Interface, filter ListFilter[policyv1.PodDisruptionBudget]) ([]policyv1.PodDisruptionBudget, error) {
+	return listNamespaced(ctx, "pod disruption budgets", filter, func(ns string) PageLister[*policyv1.PodDisruptionBudgetList] {
+		return client.PolicyV1().PodDisruptionBudgets(ns).List
+	}, func(l *policyv1.PodDisruptionBudgetList) []policyv1.PodDisruptionBudget {
+		return l.Items
+	})
+}
+
+// listNamespaced lists from each namespace of the filter in turn, or from all namespaces if none are given.
+func listNamespaced[T any, L metav1.ListInterface](ctx context.Context, resourceName string, filter ListFilter[T], listerFor func(namespace string) PageLister[L], itemsFn func(L) []T) ([]T, error) {
+	if len(filter.Namespaces) == 0 {
+		return listFiltered(ctx, resourceName, filter, listerFor(metav1.NamespaceAll), itemsFn)
+	}
+	var allItems []T
+	for _, ns := range lo.Uniq(filter.Namespaces) {
+		items, err := listFiltered(ctx, resourceName, filter, listerFor(ns), itemsFn)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list %s in namespace %q: %w", resourceName, ns, err)
+		}
+		allItems = append(allItems, items...)
+	}
+	return allItems, nil
+}
+
+func listFiltered[T any, L metav1.ListInterface](ctx context.Context, resourceName string, filter ListFilter[T], lister PageLister[L], itemsFn func(L) []T) ([]T, error) {
+	selectingLister := func(ctx context.Context, opts metav1.ListOptions) (L, error) {
+		opts.LabelSelector = filter.LabelSelector
+		opts.FieldSelector = filter.FieldSelector
+		return lister(ctx, opts)
+	}
+	matchingItems := func(l L) []T {
+		items := itemsFn(l)
+		if len(filter.Predicates) == 0 {
+			return items
+		}
+		return lo.Filter(items, func(item T, _ int) bool {
+			return filter.Matches(item)
+		})
+	}
+	return ListAllWithOptions(ctx, resourceName, selectingLister, matchingItems, filter.Paging)
+}
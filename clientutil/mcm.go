@@ -0,0 +1,366 @@
+package clientutil
+
+import (
+	"context"
+	"fmt"
+	gsc "github.com/elankath/gardener-scaling-common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
+	"time"
+)
+
+var MachineDeploymentGVR = schema.GroupVersionResource{Group: "machine.sapcloud.io", Version: "v1alpha1", Resource: "machinedeployments"}
+var MachineSetGVR = schema.GroupVersionResource{Group: "machine.sapcloud.io", Version: "v1alpha1", Resource: "machinesets"}
+var MachineGVR = schema.GroupVersionResource{Group: "machine.sapcloud.io", Version: "v1alpha1", Resource: "machines"}
+var MachineClassGVR = schema.GroupVersionResource{Group: "machine.sapcloud.io", Version: "v1alpha1", Resource: "machineclasses"}
+
+// ResourceGPU is the resource name under which the gpu capacity of a MachineClass node template is reported.
+const ResourceGPU corev1.ResourceName = "nvidia.com/gpu"
+
+// DefaultMaxPods is the pod capacity of a node template if the MachineClass does not specify one.
+const DefaultMaxPods = 110
+
+// DefaultKubeReserved are the resources reserved for the kubelet and container runtime, used when computing the
+// allocatable of a NodeTemplate if no reserved resources are given.
+var DefaultKubeReserved = corev1.ResourceList{
+	corev1.ResourceCPU:    resource.MustParse("80m"),
+	corev1.ResourceMemory: resource.MustParse("1Gi"),
+}
+
+// MachineNodeLabel is the label MCM puts on a Machine holding the name of its node.
+const MachineNodeLabel = "node"
+
+// ListUnstructured lists all objects of the given resource in the given namespace using the dynamic client.
+func ListUnstructured(ctx context.Context, client dynamic.Interface, gvr schema.GroupVersionResource, namespace string, opts PagingOptions) ([]unstructured.Unstructured, error) {
+	return ListAllWithOptions(ctx, gvr.Resource, client.Resource(gvr).Namespace(namespace).List, func(l *unstructured.UnstructuredList) []unstructured.Unstructured {
+		return l.Items
+	}, opts)
+}
+
+// ListMachineDeploymentInfos lists the MCM MachineDeployments in the given shoot control-plane namespace and
+// converts them into MachineDeploymentInfos using AsMachineDeploymentInfo.
+func ListMachineDeploymentInfos(ctx context.Context, client dynamic.Interface, namespace string) ([]gsc.MachineDeploymentInfo, error) {
+	objs, err := ListUnstructured(ctx, client, MachineDeploymentGVR, namespace, PagingOptions{})
+	if err != nil {
+		return nil, err
+	}
+	snapshotTime := time.Now()
+	mcdInfos := make([]gsc.MachineDeploymentInfo, 0, len(objs))
+	for i := range objs {
+		mcdInfo, err := AsMachineDeploymentInfo(&objs[i], snapshotTime)
+		if err != nil {
+			return nil, err
+		}
+		mcdInfos = append(mcdInfos, mcdInfo)
+	}
+	return mcdInfos, nil
+}
+
+// AsMachineDeploymentInfo converts the given unstructured MCM MachineDeployment into a MachineDeploymentInfo
+// captured at snapshotTime, including its Hash. PoolName and Zone are taken from the labels of the node template.
+func AsMachineDeploymentInfo(obj *unstructured.Unstructured, snapshotTime time.Time) (mcdInfo gsc.MachineDeploymentInfo, err error) {
+	mcdInfo.SnapshotMeta = asSnapshotMeta(obj, snapshotTime)
+	if obj.GetDeletionTimestamp() != nil {
+		mcdInfo.DeletionTimestamp = obj.GetDeletionTimestamp().UTC()
+	}
+	replicas, _, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return mcdInfo, fmt.Errorf("cannot get replicas of machine deployment %q: %w", obj.GetName(), err)
+	}
+	mcdInfo.Replicas = int(replicas)
+
+	mcdInfo.MaxSurge, err = getIntOrString(obj.Object, "spec", "strategy", "rollingUpdate", "maxSurge")
+	if err != nil {
+		return mcdInfo, fmt.Errorf("cannot get maxSurge of machine deployment %q: %w", obj.GetName(), err)
+	}
+	mcdInfo.MaxUnavailable, err = getIntOrString(obj.Object, "spec", "strategy", "rollingUpdate", "maxUnavailable")
+	if err != nil {
+		return mcdInfo, fmt.Errorf("cannot get maxUnavailable of machine deployment %q: %w", obj.GetName(), err)
+	}
+
+	mcdInfo.MachineClassName, _, err = unstructured.NestedString(obj.Object, "spec", "template", "spec", "class", "name")
+	if err != nil {
+		return mcdInfo, fmt.Errorf("cannot get machine class name of machine deployment %q: %w", obj.GetName(), err)
+	}
+
+	mcdInfo.Labels, _, err = unstructured.NestedStringMap(obj.Object, "spec", "template", "spec", "nodeTemplate", "metadata", "labels")
+	if err != nil {
+		return mcdInfo, fmt.Errorf("cannot get node template labels of machine deployment %q: %w", obj.GetName(), err)
+	}
+	mcdInfo.PoolName, _ = gsc.GetPoolName(mcdInfo.Labels)
+	mcdInfo.Zone, _ = gsc.GetZone(mcdInfo.Labels)
+
+	nodeSpecMap, found, err := unstructured.NestedMap(obj.Object, "spec", "template", "spec", "nodeTemplate", "spec")
+	if err != nil {
+		return mcdInfo, fmt.Errorf("cannot get node template spec of machine deployment %q: %w", obj.GetName(), err)
+	}
+	if found {
+		var nodeSpec corev1.NodeSpec
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(nodeSpecMap, &nodeSpec); err != nil {
+			return mcdInfo, fmt.Errorf("cannot convert node template spec of machine deployment %q: %w", obj.GetName(), err)
+		}
+		mcdInfo.Taints = nodeSpec.Taints
+	}
+	mcdInfo.Hash = mcdInfo.GetHash()
+	return
+}
+
+// ListMachineSetInfos lists the MCM MachineSets in the given shoot control-plane namespace and
+// converts them into MachineSetInfos using AsMachineSetInfo.
+func ListMachineSetInfos(ctx context.Context, client dynamic.Interface, namespace string) ([]gsc.MachineSetInfo, error) {
+	objs, err := ListUnstructured(ctx, client, MachineSetGVR, namespace, PagingOptions{})
+	if err != nil {
+		return nil, err
+	}
+	snapshotTime := time.Now()
+	msInfos := make([]gsc.MachineSetInfo, 0, len(objs))
+	for i := range objs {
+		msInfo, err := AsMachineSetInfo(&objs[i], snapshotTime)
+		if err != nil {
+			return nil, err
+		}
+		msInfos = append(msInfos, msInfo)
+	}
+	return msInfos, nil
+}
+
+// ListMachineInfos lists the MCM Machines in the given shoot control-plane namespace and
+// converts them into MachineInfos using AsMachineInfo.
+func ListMachineInfos(ctx context.Context, client dynamic.Interface, namespace string) ([]gsc.MachineInfo, error) {
+	objs, err := ListUnstructured(ctx, client, MachineGVR, namespace, PagingOptions{})
+	if err != nil {
+		return nil, err
+	}
+	snapshotTime := time.Now()
+	machineInfos := make([]gsc.MachineInfo, 0, len(objs))
+	for i := range objs {
+		machineInfo, err := AsMachineInfo(&objs[i], snapshotTime)
+		if err != nil {
+			return nil, err
+		}
+		machineInfos = append(machineInfos, machineInfo)
+	}
+	return machineInfos, nil
+}
+
+// AsMachineSetInfo converts the given unstructured MCM MachineSet into a MachineSetInfo captured at snapshotTime,
+// including its Hash.
+func AsMachineSetInfo(obj *unstructured.Unstructured, snapshotTime time.Time) (msInfo gsc.MachineSetInfo, err error) {
+	msInfo.SnapshotMeta = asSnapshotMeta(obj, snapshotTime)
+	if obj.GetDeletionTimestamp() != nil {
+		msInfo.DeletionTimestamp = obj.GetDeletionTimestamp().UTC()
+	}
+	msInfo.MachineDeploymentName = getOwnerName(obj, "MachineDeployment")
+	for _, f := range []struct {
+		target *int
+		keys   []string
+	}{
+		{&msInfo.Replicas, []string{"spec", "replicas"}},
+		{&msInfo.ReadyReplicas, []string{"status", "readyReplicas"}},
+		{&msInfo.AvailableReplicas, []string{"status", "availableReplicas"}},
+	} {
+		val, _, err := unstructured.NestedInt64(obj.Object, f.keys...)
+		if err != nil {
+			return msInfo, fmt.Errorf("cannot get %s of machine set %q: %w", f.keys[len(f.keys)-1], obj.GetName(), err)
+		}
+		*f.target = int(val)
+	}
+	msInfo.MachineClassName, _, err = unstructured.NestedString(obj.Object, "spec", "template", "spec", "class", "name")
+	if err != nil {
+		return msInfo, fmt.Errorf("cannot get machine class name of machine set %q: %w", obj.GetName(), err)
+	}
+	msInfo.Labels, _, err = unstructured.NestedStringMap(obj.Object, "spec", "template", "spec", "nodeTemplate", "metadata", "labels")
+	if err != nil {
+		return msInfo, fmt.Errorf("cannot get node template labels of machine set %q: %w", obj.GetName(), err)
+	}
+	msInfo.LastOperation, err = getMachineOperation(obj.Object, "status", "lastOperation")
+	if err != nil {
+		return msInfo, fmt.Errorf("cannot get last operation of machine set %q: %w", obj.GetName(), err)
+	}
+	failedMachines, _, err := unstructured.NestedSlice(obj.Object, "status", "failedMachines")
+	if err != nil {
+		return msInfo, fmt.Errorf("cannot get failed machines of machine set %q: %w", obj.GetName(), err)
+	}
+	for _, fm := range failedMachines {
+		if fmMap, ok := fm.(map[string]any); ok {
+			if name, ok := fmMap["name"].(string); ok {
+				msInfo.FailedMachineNames = append(msInfo.FailedMachineNames, name)
+			}
+		}
+	}
+	msInfo.Hash = msInfo.GetHash()
+	return
+}
+
+// AsMachineInfo converts the given unstructured MCM Machine into a MachineInfo captured at snapshotTime,
+// including its Hash. The NodeName is taken from status.node or else from the MachineNodeLabel.
+func AsMachineInfo(obj *unstructured.Unstructured, snapshotTime time.Time) (machineInfo gsc.MachineInfo, err error) {
+	machineInfo.SnapshotMeta = asSnapshotMeta(obj, snapshotTime)
+	if obj.GetDeletionTimestamp() != nil {
+		machineInfo.DeletionTimestamp = obj.GetDeletionTimestamp().UTC()
+	}
+	machineInfo.MachineSetName = getOwnerName(obj, "MachineSet")
+	machineInfo.Labels = obj.GetLabels()
+	for _, f := range []struct {
+		target *string
+		keys   []string
+	}{
+		{&machineInfo.MachineClassName, []string{"spec", "class", "name"}},
+		{&machineInfo.ProviderID, []string{"spec", "providerID"}},
+		{&machineInfo.NodeName, []string{"status", "node"}},
+		{&machineInfo.Phase, []string{"status", "currentStatus", "phase"}},
+	} {
+		*f.target, _, err = unstructured.NestedString(obj.Object, f.keys...)
+		if err != nil {
+			return machineInfo, fmt.Errorf("cannot get %s of machine %q: %w", f.keys[len(f.keys)-1], obj.GetName(), err)
+		}
+	}
+	if machineInfo.NodeName == "" {
+		machineInfo.NodeName = machineInfo.Labels[MachineNodeLabel]
+	}
+	machineInfo.LastOperation, err = getMachineOperation(obj.Object, "status", "lastOperation")
+	if err != nil {
+		return machineInfo, fmt.Errorf("cannot get last operation of machine %q: %w", obj.GetName(), err)
+	}
+	if machineInfo.LastOperation.State == "Failed" {
+		machineInfo.ErrorDescription = machineInfo.LastOperation.Description
+	}
+	machineInfo.Hash = machineInfo.GetHash()
+	return
+}
+
+// AsNodeTemplate converts the nodeTemplate section of the given unstructured MCM MachineClass and its owning
+// MachineDeploymentInfo into a NodeTemplate named after the machine deployment. The Allocatable is the Capacity
+// minus the given reserved resources, or DefaultKubeReserved if reserved is nil, but never below zero. Labels are
+// the node template labels of the machine deployment completed with the well-known pool, zone, region, instance
+// type, arch and os labels, and Taints are those of the machine deployment.
+func AsNodeTemplate(machineClass *unstructured.Unstructured, mcdInfo gsc.MachineDeploymentInfo, reserved corev1.ResourceList) (nt gsc.NodeTemplate, err error) {
+	nodeTemplate, found, err := unstructured.NestedMap(machineClass.Object, "nodeTemplate")
+	if err != nil {
+		return nt, fmt.Errorf("cannot get nodeTemplate of machine class %q: %w", machineClass.GetName(), err)
+	}
+	if !found {
+		return nt, fmt.Errorf("cannot find nodeTemplate of machine class %q: %w", machineClass.GetName(), gsc.ErrKeyNotFound)
+	}
+	nt.Name = mcdInfo.Name
+	nt.InstanceType, _, _ = unstructured.NestedString(nodeTemplate, "instanceType")
+	nt.Region, _, _ = unstructured.NestedString(nodeTemplate, "region")
+	nt.Zone, _, _ = unstructured.NestedString(nodeTemplate, "zone")
+	if nt.Zone == "" {
+		nt.Zone = mcdInfo.Zone
+	}
+	architecture, _, _ := unstructured.NestedString(nodeTemplate, "architecture")
+	if architecture == "" {
+		architecture = gsc.DefaultArchitecture
+	}
+
+	capacity, _, err := unstructured.NestedStringMap(nodeTemplate, "capacity")
+	if err != nil {
+		return nt, fmt.Errorf("cannot get nodeTemplate.capacity of machine class %q: %w", machineClass.GetName(), err)
+	}
+	nt.Capacity = make(corev1.ResourceList, len(capacity)+1)
+	for name, val := range capacity {
+		q, err := gsc.AsQuantity(val)
+		if err != nil {
+			return nt, fmt.Errorf("cannot parse capacity %q of machine class %q: %w", name, machineClass.GetName(), err)
+		}
+		resourceName := corev1.ResourceName(name)
+		if resourceName == "gpu" {
+			if q.IsZero() {
+				continue
+			}
+			resourceName = ResourceGPU
+		}
+		nt.Capacity[resourceName] = q
+	}
+	if _, ok := nt.Capacity[corev1.ResourcePods]; !ok {
+		nt.Capacity[corev1.ResourcePods] = *resource.NewQuantity(DefaultMaxPods, resource.DecimalSI)
+	}
+	if reserved == nil {
+		reserved = DefaultKubeReserved
+	}
+	nt.Allocatable = nt.Capacity.DeepCopy()
+	for name, q := range reserved {
+		if allocatable, ok := nt.Allocatable[name]; ok {
+			allocatable.Sub(q)
+			if allocatable.Sign() < 0 {
+				allocatable.Set(0)
+			}
+			nt.Allocatable[name] = allocatable
+		}
+	}
+
+	nt.Labels = make(map[string]string, len(mcdInfo.Labels)+8)
+	for k, v := range mcdInfo.Labels {
+		nt.Labels[k] = v
+	}
+	wellKnownLabels := map[string]string{
+		gsc.PoolLabel:                       mcdInfo.PoolName,
+		corev1.LabelTopologyZone:            nt.Zone,
+		corev1.LabelFailureDomainBetaZone:   nt.Zone,
+		corev1.LabelTopologyRegion:          nt.Region,
+		corev1.LabelFailureDomainBetaRegion: nt.Region,
+		corev1.LabelInstanceTypeStable:      nt.InstanceType,
+		corev1.LabelInstanceType:            nt.InstanceType,
+		corev1.LabelArchStable:              architecture,
+		corev1.LabelOSStable:                string(corev1.Linux),
+	}
+	for k, v := range wellKnownLabels {
+		if _, ok := nt.Labels[k]; !ok && v != "" {
+			nt.Labels[k] = v
+		}
+	}
+	nt.Taints = mcdInfo.Taints
+	nt.Hash = nt.GetHash()
+	return
+}
+
+func asSnapshotMeta(obj *unstructured.Unstructured, snapshotTime time.Time) gsc.SnapshotMeta {
+	return gsc.SnapshotMeta{
+		CreationTimestamp: obj.GetCreationTimestamp().UTC(),
+		SnapshotTimestamp: snapshotTime.UTC(),
+		Name:              obj.GetName(),
+		Namespace:         obj.GetNamespace(),
+	}
+}
+
+func getOwnerName(obj *unstructured.Unstructured, ownerKind string) string {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Kind == ownerKind {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+func getMachineOperation(obj map[string]any, keys ...string) (op gsc.MachineOperationInfo, err error) {
+	opMap, found, err := unstructured.NestedStringMap(obj, keys...)
+	if err != nil || !found {
+		return
+	}
+	op = gsc.MachineOperationInfo{
+		Type:        opMap["type"],
+		State:       opMap["state"],
+		Description: opMap["description"],
+		ErrorCode:   opMap["errorCode"],
+	}
+	if lastUpdateTime := opMap["lastUpdateTime"]; lastUpdateTime != "" {
+		op.LastUpdateTime, err = time.Parse(time.RFC3339, lastUpdateTime)
+	}
+	return
+}
+
+// getIntOrString returns the value under the given keys as intstr.IntOrString or the zero value if it or one of
+// its parent maps is absent. It fails if a parent is not a map or the value is neither an integer nor a string.
+func getIntOrString(obj map[string]any, keys ...string) (intstr.IntOrString, error) {
+	val, found, err := unstructured.NestedFieldNoCopy(obj, keys...)
+	if err != nil || !found || val == nil {
+		return intstr.IntOrString{}, err
+	}
+	return gsc.AsIntOrString(val)
+}
@@ -0,0 +1,299 @@
+package clientutil
+
+import (
+	gsc "github.com/elankath/gardener-scaling-common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sigs.k8s.io/yaml"
+	"slices"
+	"testing"
+	"time"
+)
+
+// loadUnstructured reads the YAML fixture with the given name from testdata. It converts the YAML to JSON first
+// so that integers decode as int64, the same as objects returned by the dynamic client.
+func loadUnstructured(t *testing.T, name string) *unstructured.Unstructured {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("cannot read fixture %q: %v", name, err)
+	}
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		t.Fatalf("cannot convert fixture %q to JSON: %v", name, err)
+	}
+	obj := &unstructured.Unstructured{}
+	if err = obj.UnmarshalJSON(data); err != nil {
+		t.Fatalf("cannot decode fixture %q: %v", name, err)
+	}
+	return obj
+}
+
+func TestAsMachineDeploymentInfo(t *testing.T) {
+	snapshotTime := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name               string
+		mutate             func(obj map[string]any)
+		wantMaxSurge       intstr.IntOrString
+		wantMaxUnavailable intstr.IntOrString
+		wantErr            bool
+	}{
+		{
+			name:               "integer rolling update",
+			wantMaxSurge:       intstr.FromInt32(1),
+			wantMaxUnavailable: intstr.FromInt32(0),
+		},
+		{
+			name: "percentage rolling update",
+			mutate: func(obj map[string]any) {
+				_ = unstructured.SetNestedField(obj, "25%", "spec", "strategy", "rollingUpdate", "maxSurge")
+			},
+			wantMaxSurge:       intstr.FromString("25%"),
+			wantMaxUnavailable: intstr.FromInt32(0),
+		},
+		{
+			name: "missing rolling update",
+			mutate: func(obj map[string]any) {
+				unstructured.RemoveNestedField(obj, "spec", "strategy", "rollingUpdate")
+			},
+		},
+		{
+			name: "rolling update of wrong type",
+			mutate: func(obj map[string]any) {
+				_ = unstructured.SetNestedField(obj, "RollingUpdate", "spec", "strategy", "rollingUpdate")
+			},
+			wantErr: true,
+		},
+		{
+			name: "max surge of wrong type",
+			mutate: func(obj map[string]any) {
+				_ = unstructured.SetNestedField(obj, true, "spec", "strategy", "rollingUpdate", "maxSurge")
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := loadUnstructured(t, "machinedeployment.yaml")
+			if tc.mutate != nil {
+				tc.mutate(obj.Object)
+			}
+			mcdInfo, err := AsMachineDeploymentInfo(obj, snapshotTime)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("cannot convert machine deployment: %v", err)
+			}
+			if mcdInfo.MaxSurge != tc.wantMaxSurge || mcdInfo.MaxUnavailable != tc.wantMaxUnavailable {
+				t.Errorf("expected maxSurge %v and maxUnavailable %v, got %v and %v", tc.wantMaxSurge, tc.wantMaxUnavailable, mcdInfo.MaxSurge, mcdInfo.MaxUnavailable)
+			}
+			if mcdInfo.Name != "shoot--garden--aws-a-z1" || mcdInfo.Namespace != "shoot--garden--aws" || mcdInfo.Replicas != 2 {
+				t.Errorf("expected 2 replicas of shoot--garden--aws/shoot--garden--aws-a-z1, got %d of %s/%s", mcdInfo.Replicas, mcdInfo.Namespace, mcdInfo.Name)
+			}
+			if want := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC); !mcdInfo.CreationTimestamp.Equal(want) || !mcdInfo.SnapshotTimestamp.Equal(snapshotTime) {
+				t.Errorf("expected creation time %s and snapshot time %s, got %s and %s", want, snapshotTime, mcdInfo.CreationTimestamp, mcdInfo.SnapshotTimestamp)
+			}
+			if mcdInfo.MachineClassName != "shoot--garden--aws-a-z1-8ab1c" {
+				t.Errorf("expected machine class shoot--garden--aws-a-z1-8ab1c, got %q", mcdInfo.MachineClassName)
+			}
+			if mcdInfo.PoolName != "a" || mcdInfo.Zone != "eu-west-1a" {
+				t.Errorf("expected pool a in zone eu-west-1a, got pool %q in zone %q", mcdInfo.PoolName, mcdInfo.Zone)
+			}
+			if !maps.Equal(mcdInfo.Labels, map[string]string{
+				"node.kubernetes.io/role":                 "node",
+				"topology.kubernetes.io/zone":             "eu-west-1a",
+				"worker.gardener.cloud/pool":              "a",
+				"worker.gardener.cloud/system-components": "true",
+			}) {
+				t.Errorf("unexpected node template labels %v", mcdInfo.Labels)
+			}
+			wantTaints := []corev1.Taint{{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}}
+			if !reflect.DeepEqual(mcdInfo.Taints, wantTaints) {
+				t.Errorf("expected taints %v, got %v", wantTaints, mcdInfo.Taints)
+			}
+			if mcdInfo.Hash == "" || mcdInfo.Hash != mcdInfo.GetHash() {
+				t.Errorf("expected hash to be set, got %q", mcdInfo.Hash)
+			}
+		})
+	}
+}
+
+func TestAsMachineSetInfo(t *testing.T) {
+	snapshotTime := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	msInfo, err := AsMachineSetInfo(loadUnstructured(t, "machineset.yaml"), snapshotTime)
+	if err != nil {
+		t.Fatalf("cannot convert machine set: %v", err)
+	}
+	if msInfo.Name != "shoot--garden--aws-a-z1-5b8c9" || msInfo.MachineDeploymentName != "shoot--garden--aws-a-z1" {
+		t.Errorf("expected machine set shoot--garden--aws-a-z1-5b8c9 owned by shoot--garden--aws-a-z1, got %q owned by %q", msInfo.Name, msInfo.MachineDeploymentName)
+	}
+	if msInfo.Replicas != 3 || msInfo.ReadyReplicas != 2 || msInfo.AvailableReplicas != 2 {
+		t.Errorf("expected 3 replicas with 2 ready and available, got %d with %d ready and %d available", msInfo.Replicas, msInfo.ReadyReplicas, msInfo.AvailableReplicas)
+	}
+	if msInfo.MachineClassName != "shoot--garden--aws-a-z1-8ab1c" || msInfo.Labels[gsc.PoolLabel] != "a" {
+		t.Errorf("expected machine class shoot--garden--aws-a-z1-8ab1c and pool a, got %q and labels %v", msInfo.MachineClassName, msInfo.Labels)
+	}
+	wantOp := gsc.MachineOperationInfo{Type: "Create", State: "Processing", LastUpdateTime: time.Date(2024, 7, 1, 9, 5, 0, 0, time.UTC)}
+	if msInfo.LastOperation != wantOp {
+		t.Errorf("expected last operation %+v, got %+v", wantOp, msInfo.LastOperation)
+	}
+	if !slices.Equal(msInfo.FailedMachineNames, []string{"shoot--garden--aws-a-z1-5b8c9-qp7rt"}) {
+		t.Errorf("expected failed machine shoot--garden--aws-a-z1-5b8c9-qp7rt, got %v", msInfo.FailedMachineNames)
+	}
+	if msInfo.Hash == "" || msInfo.Hash != msInfo.GetHash() {
+		t.Errorf("expected hash to be set, got %q", msInfo.Hash)
+	}
+
+	obj := loadUnstructured(t, "machineset.yaml")
+	_ = unstructured.SetNestedField(obj.Object, "3", "spec", "replicas")
+	if _, err = AsMachineSetInfo(obj, snapshotTime); err == nil {
+		t.Error("expected error for replicas of wrong type")
+	}
+}
+
+func TestAsMachineInfo(t *testing.T) {
+	snapshotTime := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	failedOp := gsc.MachineOperationInfo{
+		Type:           "Create",
+		State:          "Failed",
+		Description:    "Cloud provider message - machine codes error: code = [ResourceExhausted] message = [InsufficientInstanceCapacity]",
+		ErrorCode:      "ResourceExhausted",
+		LastUpdateTime: time.Date(2024, 7, 1, 9, 5, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		fixture              string
+		wantName             string
+		wantProviderID       string
+		wantNodeName         string
+		wantPhase            string
+		wantLastOperation    gsc.MachineOperationInfo
+		wantErrorDescription string
+		wantDeletion         time.Time
+	}{
+		{
+			fixture:        "machine-running.yaml",
+			wantName:       "shoot--garden--aws-a-z1-5b8c9-xk2lp",
+			wantProviderID: "aws:///eu-west-1a/i-0a1b2c3d4e5f60718",
+			wantNodeName:   "ip-10-180-12-34.eu-west-1.compute.internal",
+			wantPhase:      "Running",
+			wantLastOperation: gsc.MachineOperationInfo{
+				Type:           "Create",
+				State:          "Successful",
+				Description:    "Machine shoot--garden--aws-a-z1-5b8c9-xk2lp successfully joined the cluster",
+				LastUpdateTime: time.Date(2024, 7, 1, 9, 3, 0, 0, time.UTC),
+			},
+		},
+		{
+			fixture:              "machine-failed.yaml",
+			wantName:             "shoot--garden--aws-a-z1-5b8c9-qp7rt",
+			wantPhase:            "Failed",
+			wantLastOperation:    failedOp,
+			wantErrorDescription: failedOp.Description,
+			wantDeletion:         time.Date(2024, 7, 1, 9, 6, 0, 0, time.UTC),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.fixture, func(t *testing.T) {
+			machineInfo, err := AsMachineInfo(loadUnstructured(t, tc.fixture), snapshotTime)
+			if err != nil {
+				t.Fatalf("cannot convert machine: %v", err)
+			}
+			if machineInfo.Name != tc.wantName || machineInfo.MachineSetName != "shoot--garden--aws-a-z1-5b8c9" {
+				t.Errorf("expected machine %q owned by shoot--garden--aws-a-z1-5b8c9, got %q owned by %q", tc.wantName, machineInfo.Name, machineInfo.MachineSetName)
+			}
+			if machineInfo.MachineClassName != "shoot--garden--aws-a-z1-8ab1c" {
+				t.Errorf("expected machine class shoot--garden--aws-a-z1-8ab1c, got %q", machineInfo.MachineClassName)
+			}
+			if machineInfo.ProviderID != tc.wantProviderID || machineInfo.NodeName != tc.wantNodeName || machineInfo.Phase != tc.wantPhase {
+				t.Errorf("expected providerID %q, node %q and phase %q, got %q, %q and %q", tc.wantProviderID, tc.wantNodeName, tc.wantPhase, machineInfo.ProviderID, machineInfo.NodeName, machineInfo.Phase)
+			}
+			if machineInfo.LastOperation != tc.wantLastOperation || machineInfo.ErrorDescription != tc.wantErrorDescription {
+				t.Errorf("expected last operation %+v with error %q, got %+v with error %q", tc.wantLastOperation, tc.wantErrorDescription, machineInfo.LastOperation, machineInfo.ErrorDescription)
+			}
+			if !machineInfo.DeletionTimestamp.Equal(tc.wantDeletion) {
+				t.Errorf("expected deletion timestamp %s, got %s", tc.wantDeletion, machineInfo.DeletionTimestamp)
+			}
+			if machineInfo.Hash == "" || machineInfo.Hash != machineInfo.GetHash() {
+				t.Errorf("expected hash to be set, got %q", machineInfo.Hash)
+			}
+		})
+	}
+}
+
+func TestAsNodeTemplate(t *testing.T) {
+	mcdInfo, err := AsMachineDeploymentInfo(loadUnstructured(t, "machinedeployment.yaml"), time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("cannot convert machine deployment: %v", err)
+	}
+	tests := []struct {
+		name            string
+		reserved        corev1.ResourceList
+		wantAllocatable corev1.ResourceList
+	}{
+		{
+			name: "default reserved exceeding capacity",
+			wantAllocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("0"),
+				corev1.ResourceMemory: resource.MustParse("0"),
+				corev1.ResourcePods:   resource.MustParse("110"),
+			},
+		},
+		{
+			name: "reserved within capacity",
+			reserved: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+			},
+			wantAllocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("40m"),
+				corev1.ResourceMemory: resource.MustParse("384Mi"),
+				corev1.ResourcePods:   resource.MustParse("110"),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nt, err := AsNodeTemplate(loadUnstructured(t, "machineclass-tiny.yaml"), mcdInfo, tc.reserved)
+			if err != nil {
+				t.Fatalf("cannot convert machine class: %v", err)
+			}
+			wantCapacity := corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("50m"),
+				corev1.ResourceMemory: resource.MustParse("512Mi"),
+				corev1.ResourcePods:   resource.MustParse("110"),
+			}
+			for _, c := range []struct {
+				kind      string
+				got, want corev1.ResourceList
+			}{
+				{"capacity", nt.Capacity, wantCapacity},
+				{"allocatable", nt.Allocatable, tc.wantAllocatable},
+			} {
+				if len(c.got) != len(c.want) {
+					t.Errorf("expected %s %v, got %v", c.kind, c.want, c.got)
+				}
+				for name, want := range c.want {
+					if got := c.got[name]; got.Cmp(want) != 0 {
+						t.Errorf("expected %s %s of %s, got %s", c.kind, name, want.String(), got.String())
+					}
+				}
+			}
+			if nt.Name != mcdInfo.Name || nt.InstanceType != "t4g.nano" || nt.Zone != "eu-west-1a" {
+				t.Errorf("unexpected node template %s of instance type %q in zone %q", nt.Name, nt.InstanceType, nt.Zone)
+			}
+			if nt.Labels[corev1.LabelArchStable] != "arm64" || nt.Labels[corev1.LabelInstanceTypeStable] != "t4g.nano" || nt.Labels[gsc.PoolLabel] != "a" {
+				t.Errorf("unexpected node template labels %v", nt.Labels)
+			}
+		})
+	}
+}
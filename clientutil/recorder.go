@@ -0,0 +1,246 @@
+package clientutil
+
+import (
+	"context"
+	"fmt"
+	gsc "github.com/elankath/gardener-scaling-common"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"sync"
+	"time"
+)
+
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "Added"
+	ChangeUpdated ChangeType = "Updated"
+	ChangeDeleted ChangeType = "Deleted"
+)
+
+// InfoChange is a change emitted by the Recorder. Exactly one of the info pointers is set.
+type InfoChange struct {
+	Type              ChangeType
+	PodInfo           *gsc.PodInfo
+	NodeInfo          *gsc.NodeInfo
+	PriorityClassInfo *gsc.PriorityClassInfo
+	EventInfo         *gsc.EventInfo
+}
+
+func (c InfoChange) String() string {
+	switch {
+	case c.PodInfo != nil:
+		return fmt.Sprintf("InfoChange(Type=%s, %s)", c.Type, c.PodInfo)
+	case c.NodeInfo != nil:
+		return fmt.Sprintf("InfoChange(Type=%s, %s)", c.Type, c.NodeInfo)
+	case c.PriorityClassInfo != nil:
+		return fmt.Sprintf("InfoChange(Type=%s, %s)", c.Type, c.PriorityClassInfo)
+	case c.EventInfo != nil:
+		return fmt.Sprintf("InfoChange(Type=%s, %s)", c.Type, c.EventInfo)
+	}
+	return fmt.Sprintf("InfoChange(Type=%s)", c.Type)
+}
+
+// Recorder watches pods, nodes, priority classes and events using shared informers and delivers every change
+// as an InfoChange on the Changes channel. Updates that neither change the GetHash of an info nor its
+// DeletionTimestamp are suppressed. The informer event handlers block until a change has been received, so a
+// slow consumer applies backpressure on the informers.
+type Recorder struct {
+	factory informers.SharedInformerFactory
+	changes chan InfoChange
+	ctx     context.Context
+	mu      sync.Mutex
+	// lastPods holds the last emitted PodInfo per pod UID.
+	lastPods map[string]gsc.PodInfo
+	// lastNodes holds the last emitted NodeInfo per node name.
+	lastNodes map[string]gsc.NodeInfo
+	// lastPriorityClasses holds the hash of the last emitted PriorityClassInfo per priority class name.
+	lastPriorityClasses map[string]string
+	// lastEvents holds the last emitted EventInfo per event UID.
+	lastEvents map[string]gsc.EventInfo
+}
+
+// NewRecorder creates a Recorder whose Changes channel has the given buffer size.
+func NewRecorder(client kubernetes.Interface, resyncPeriod time.Duration, bufferSize int) *Recorder {
+	return &Recorder{
+		factory:             informers.NewSharedInformerFactory(client, resyncPeriod),
+		changes:             make(chan InfoChange, bufferSize),
+		lastPods:            make(map[string]gsc.PodInfo),
+		lastNodes:           make(map[string]gsc.NodeInfo),
+		lastPriorityClasses: make(map[string]string),
+		lastEvents:          make(map[string]gsc.EventInfo),
+	}
+}
+
+// Changes returns the channel on which changes are delivered. It is closed once the context passed to Start is done.
+func (r *Recorder) Changes() <-chan InfoChange {
+	return r.changes
+}
+
+// Start registers the event handlers, starts the informers and waits for their caches to sync.
+func (r *Recorder) Start(ctx context.Context) error {
+	r.ctx = ctx
+	handlers := []struct {
+		informer cache.SharedIndexInformer
+		handler  cache.ResourceEventHandlerFuncs
+	}{
+		{r.factory.Core().V1().Pods().Informer(), cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj any) { r.onPod(obj, ChangeAdded) },
+			UpdateFunc: func(_, obj any) { r.onPod(obj, ChangeUpdated) },
+			DeleteFunc: func(obj any) { r.onPod(obj, ChangeDeleted) },
+		}},
+		{r.factory.Core().V1().Nodes().Informer(), cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj any) { r.onNode(obj, ChangeAdded) },
+			UpdateFunc: func(_, obj any) { r.onNode(obj, ChangeUpdated) },
+			DeleteFunc: func(obj any) { r.onNode(obj, ChangeDeleted) },
+		}},
+		{r.factory.Scheduling().V1().PriorityClasses().Informer(), cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj any) { r.onPriorityClass(obj, ChangeAdded) },
+			UpdateFunc: func(_, obj any) { r.onPriorityClass(obj, ChangeUpdated) },
+			DeleteFunc: func(obj any) { r.onPriorityClass(obj, ChangeDeleted) },
+		}},
+		{r.factory.Core().V1().Events().Informer(), cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj any) { r.onEvent(obj, ChangeAdded) },
+			UpdateFunc: func(_, obj any) { r.onEvent(obj, ChangeUpdated) },
+			DeleteFunc: r.forgetEvent,
+		}},
+	}
+	for _, h := range handlers {
+		if _, err := h.informer.AddEventHandler(h.handler); err != nil {
+			return fmt.Errorf("cannot add event handler to informer: %w", err)
+		}
+	}
+	r.factory.Start(ctx.Done())
+	// Started before waiting for the caches so that the informers are shut down and the channel is closed even
+	// if the caches never sync.
+	go func() {
+		<-ctx.Done()
+		// Shutdown waits for all handlers to return, so no emit can race with closing the channel.
+		r.factory.Shutdown()
+		close(r.changes)
+	}()
+	for informerType, synced := range r.factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("cache for informer of type %s did not sync", informerType)
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) onPod(obj any, changeType ChangeType) {
+	pod, ok := unwrapTombstone(obj).(*corev1.Pod)
+	if !ok {
+		return
+	}
+	podInfo := gsc.AsPodInfo(pod, time.Now())
+	r.mu.Lock()
+	last, found := r.lastPods[podInfo.UID]
+	if changeType == ChangeDeleted {
+		delete(r.lastPods, podInfo.UID)
+		if podInfo.DeletionTimestamp.IsZero() {
+			podInfo.DeletionTimestamp = podInfo.SnapshotTimestamp
+		}
+	} else {
+		if found && last.Hash == podInfo.Hash && last.DeletionTimestamp.Equal(podInfo.DeletionTimestamp) {
+			r.mu.Unlock()
+			return
+		}
+		r.lastPods[podInfo.UID] = podInfo
+	}
+	r.mu.Unlock()
+	r.emit(InfoChange{Type: changeType, PodInfo: &podInfo})
+}
+
+func (r *Recorder) onNode(obj any, changeType ChangeType) {
+	node, ok := unwrapTombstone(obj).(*corev1.Node)
+	if !ok {
+		return
+	}
+	nodeInfo := gsc.AsNodeInfo(node, time.Now())
+	r.mu.Lock()
+	last, found := r.lastNodes[nodeInfo.Name]
+	if changeType == ChangeDeleted {
+		delete(r.lastNodes, nodeInfo.Name)
+		if nodeInfo.DeletionTimestamp.IsZero() {
+			nodeInfo.DeletionTimestamp = nodeInfo.SnapshotTimestamp
+		}
+	} else {
+		if found && last.Hash == nodeInfo.Hash {
+			if last.DeletionTimestamp.Equal(nodeInfo.DeletionTimestamp) {
+				r.mu.Unlock()
+				return
+			}
+			// only the DeletionTimestamp changed: re-emit the last captured snapshot with it updated.
+			last.DeletionTimestamp = nodeInfo.DeletionTimestamp
+			nodeInfo = last
+		}
+		r.lastNodes[nodeInfo.Name] = nodeInfo
+	}
+	r.mu.Unlock()
+	r.emit(InfoChange{Type: changeType, NodeInfo: &nodeInfo})
+}
+
+func (r *Recorder) onPriorityClass(obj any, changeType ChangeType) {
+	pc, ok := unwrapTombstone(obj).(*schedulingv1.PriorityClass)
+	if !ok {
+		return
+	}
+	pcInfo := gsc.AsPriorityClassInfo(pc, time.Now())
+	r.mu.Lock()
+	if changeType == ChangeDeleted {
+		delete(r.lastPriorityClasses, pc.Name)
+	} else {
+		if r.lastPriorityClasses[pc.Name] == pcInfo.Hash {
+			r.mu.Unlock()
+			return
+		}
+		r.lastPriorityClasses[pc.Name] = pcInfo.Hash
+	}
+	r.mu.Unlock()
+	r.emit(InfoChange{Type: changeType, PriorityClassInfo: &pcInfo})
+}
+
+func (r *Recorder) onEvent(obj any, changeType ChangeType) {
+	event, ok := unwrapTombstone(obj).(*corev1.Event)
+	if !ok {
+		return
+	}
+	eventInfo := gsc.AsEventInfo(event)
+	r.mu.Lock()
+	if last, found := r.lastEvents[eventInfo.UID]; found && last == eventInfo {
+		r.mu.Unlock()
+		return
+	}
+	r.lastEvents[eventInfo.UID] = eventInfo
+	r.mu.Unlock()
+	r.emit(InfoChange{Type: changeType, EventInfo: &eventInfo})
+}
+
+// forgetEvent drops the state kept for an event expired by the apiserver. No change is emitted for it.
+func (r *Recorder) forgetEvent(obj any) {
+	event, ok := unwrapTombstone(obj).(*corev1.Event)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	delete(r.lastEvents, string(event.UID))
+	r.mu.Unlock()
+}
+
+// emit blocks until the change is received or the recorder context is done.
+func (r *Recorder) emit(change InfoChange) {
+	select {
+	case r.changes <- change:
+	case <-r.ctx.Done():
+	}
+}
+
+func unwrapTombstone(obj any) any {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+	return obj
+}
@@ -0,0 +1,167 @@
+package clientutil
+
+import (
+	"context"
+	"errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"testing"
+	"time"
+)
+
+func nextChange(t *testing.T, changes <-chan InfoChange) InfoChange {
+	t.Helper()
+	select {
+	case change, ok := <-changes:
+		if !ok {
+			t.Fatal("changes channel closed unexpectedly")
+		}
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+	return InfoChange{}
+}
+
+func expectNoChange(t *testing.T, changes <-chan InfoChange) {
+	t.Helper()
+	select {
+	case change := <-changes:
+		t.Fatalf("expected no change, got %s", change)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func expectClosed(t *testing.T, changes <-chan InfoChange) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-changes:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for changes channel to be closed")
+		}
+	}
+}
+
+func newRecorderTestPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", UID: "uid-web"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "web"}},
+			Tolerations: []corev1.Toleration{
+				{Key: "z", Operator: corev1.TolerationOpExists},
+				{Key: "a", Operator: corev1.TolerationOpExists},
+			},
+		},
+	}
+}
+
+func TestRecorderDeduplicatesPodChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := fake.NewSimpleClientset()
+	recorder := NewRecorder(client, 0, 10)
+	if err := recorder.Start(ctx); err != nil {
+		t.Fatalf("cannot start recorder: %v", err)
+	}
+	pods := client.CoreV1().Pods("shop")
+
+	pod, err := pods.Create(ctx, newRecorderTestPod(), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change := nextChange(t, recorder.Changes()); change.Type != ChangeAdded || change.PodInfo == nil || change.PodInfo.Name != "web" {
+		t.Fatalf("expected pod added change, got %s", change)
+	}
+
+	pod.Annotations = map[string]string{"irrelevant": "true"}
+	if pod, err = pods.Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectNoChange(t, recorder.Changes())
+	cached, err := recorder.factory.Core().V1().Pods().Lister().Pods("shop").Get("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Spec.Tolerations[0].Key != "z" {
+		t.Errorf("expected informer cache to keep toleration order, got %v", cached.Spec.Tolerations)
+	}
+
+	deletionTimestamp := metav1.NewTime(time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC))
+	pod.DeletionTimestamp = &deletionTimestamp
+	if _, err = pods.Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	change := nextChange(t, recorder.Changes())
+	if change.Type != ChangeUpdated || !change.PodInfo.DeletionTimestamp.Equal(deletionTimestamp.Time) {
+		t.Fatalf("expected pod updated change with deletion timestamp, got %s", change)
+	}
+
+	if err = pods.Delete(ctx, "web", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if change = nextChange(t, recorder.Changes()); change.Type != ChangeDeleted || change.PodInfo == nil {
+		t.Fatalf("expected pod deleted change, got %s", change)
+	}
+
+	cancel()
+	expectClosed(t, recorder.Changes())
+}
+
+func TestRecorderHandlesTombstonesAndForgottenEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recorder := NewRecorder(fake.NewSimpleClientset(), 0, 10)
+	recorder.ctx = ctx
+
+	pod := newRecorderTestPod()
+	recorder.onPod(pod, ChangeAdded)
+	nextChange(t, recorder.Changes())
+	recorder.onPod(cache.DeletedFinalStateUnknown{Key: "shop/web", Obj: pod}, ChangeDeleted)
+	change := nextChange(t, recorder.Changes())
+	if change.Type != ChangeDeleted || change.PodInfo == nil || change.PodInfo.DeletionTimestamp.IsZero() {
+		t.Fatalf("expected pod deleted change with deletion timestamp from tombstone, got %s", change)
+	}
+
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web.1", Namespace: "shop", UID: "uid-event"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web", Namespace: "shop", UID: "uid-web"},
+		Reason:         "FailedScheduling",
+		Message:        "0/3 nodes are available",
+	}
+	recorder.onEvent(event, ChangeAdded)
+	if change = nextChange(t, recorder.Changes()); change.EventInfo == nil || change.EventInfo.UID != "uid-event" {
+		t.Fatalf("expected event change, got %s", change)
+	}
+	recorder.onEvent(event, ChangeUpdated)
+	expectNoChange(t, recorder.Changes())
+	recorder.forgetEvent(cache.DeletedFinalStateUnknown{Key: "shop/web.1", Obj: event})
+	expectNoChange(t, recorder.Changes())
+	recorder.onEvent(event, ChangeAdded)
+	if change = nextChange(t, recorder.Changes()); change.EventInfo == nil {
+		t.Fatalf("expected forgotten event to be emitted again, got %s", change)
+	}
+}
+
+func TestRecorderClosesChangesWhenCachesDoNotSync(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("apiserver unavailable")
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	recorder := NewRecorder(client, 0, 10)
+
+	if err := recorder.Start(ctx); err == nil {
+		t.Fatal("expected start to fail when caches do not sync")
+	}
+	expectClosed(t, recorder.Changes())
+}
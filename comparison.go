@@ -0,0 +1,223 @@
+package gsc
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang.org/x/exp/maps"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// NodeGroupComparison compares the real and the simulated outcome of a replay step for a single node group.
+type NodeGroupComparison struct {
+	NodeGroupName string `json:"nodeGroupName"`
+	InstanceType  string `json:"instanceType"`
+	// RealScaleUps and SimulatedScaleUps are the numbers of nodes of the node group that are not part of the base snapshot.
+	RealScaleUps      int `json:"realScaleUps"`
+	SimulatedScaleUps int `json:"simulatedScaleUps"`
+	// PodsUnscheduledInReality are the pods the simulation scheduled on the node group that were left unscheduled in reality.
+	PodsUnscheduledInReality []string `json:"podsUnscheduledInReality,omitempty"`
+	// PodsUnscheduledInSimulation are the pods scheduled on the node group in reality that the simulation left unscheduled.
+	PodsUnscheduledInSimulation []string `json:"podsUnscheduledInSimulation,omitempty"`
+	// ExtraCost is the hourly price of the instance type times the difference of simulated and real scale-ups.
+	ExtraCost float64 `json:"extraCost"`
+	// RealTimeToSchedule and SimulatedTimeToSchedule are the mean durations from creation until binding of the pods
+	// that were unscheduled in the base snapshot and got scheduled on the node group. Binding times are taken from
+	// Scheduled events; pods without such an event are left out and zero means no binding time is known.
+	RealTimeToSchedule      time.Duration `json:"realTimeToSchedule"`
+	SimulatedTimeToSchedule time.Duration `json:"simulatedTimeToSchedule"`
+}
+
+// GetTimeToScheduleDiff returns how much longer the pods took to be scheduled in simulation than in reality.
+func (n NodeGroupComparison) GetTimeToScheduleDiff() time.Duration {
+	return n.SimulatedTimeToSchedule - n.RealTimeToSchedule
+}
+
+// ReplayComparison compares the real outcome of a base snapshot, which is the recorded next snapshot, with the
+// outcome simulated by the virtual autoscaler.
+type ReplayComparison struct {
+	BaseSnapshotID           string                `json:"baseSnapshotID"`
+	BaseTime                 time.Time             `json:"baseTime"`
+	RealTime                 time.Time             `json:"realTime"`
+	SimulatedTime            time.Time             `json:"simulatedTime"`
+	NodeGroups               []NodeGroupComparison `json:"nodeGroups"`
+	RealUnscheduledPods      []string              `json:"realUnscheduledPods,omitempty"`
+	SimulatedUnscheduledPods []string              `json:"simulatedUnscheduledPods,omitempty"`
+	TotalExtraCost           float64               `json:"totalExtraCost"`
+}
+
+// CompareReplayOutcome compares the recorded next snapshot with the simulated outcome of the given base snapshot per
+// node group of the AutoscalerConfig of the base snapshot. Nodes are resolved to node groups with a NodeGroupResolver,
+// and instancePrices maps instance types to their hourly price; instance types without a price incur no cost.
+// recordedEvents and simulatedEvents are the events of the real and the virtual cluster whose Scheduled events give
+// the binding times of pods. Pods and events are matched to the pods of the base snapshot by namespace and name.
+func CompareReplayOutcome(base, recorded, simulated ClusterSnapshot, recordedEvents, simulatedEvents []EventInfo, instancePrices map[string]float64) ReplayComparison {
+	resolver := NewNodeGroupResolver(base.AutoscalerConfig.NodeGroups, nil, nil)
+	comparisons := make(map[string]*NodeGroupComparison, len(base.AutoscalerConfig.NodeGroups))
+	for name := range base.AutoscalerConfig.NodeGroups {
+		comparisons[name] = &NodeGroupComparison{
+			NodeGroupName: name,
+			InstanceType:  base.AutoscalerConfig.NodeTemplates[name].InstanceType,
+		}
+	}
+	baseNodeNames := make(map[string]bool, len(base.Nodes))
+	for _, n := range base.Nodes {
+		baseNodeNames[n.Name] = true
+	}
+	realNodeGroups := nodeGroupsByNodeName(resolver, recorded.Nodes)
+	simulatedNodeGroups := nodeGroupsByNodeName(resolver, simulated.Nodes)
+	for _, n := range recorded.Nodes {
+		if c, ok := comparisons[realNodeGroups[n.Name]]; ok && !baseNodeNames[n.Name] {
+			c.RealScaleUps++
+		}
+	}
+	for _, n := range simulated.Nodes {
+		if c, ok := comparisons[simulatedNodeGroups[n.Name]]; ok && !baseNodeNames[n.Name] {
+			c.SimulatedScaleUps++
+		}
+	}
+
+	realPods := podsByName(recorded.Pods)
+	simulatedPods := podsByName(simulated.Pods)
+	realScheduleTimes := scheduleTimesByPodName(recordedEvents)
+	simulatedScheduleTimes := scheduleTimesByPodName(simulatedEvents)
+	realDurations := make(map[string][]time.Duration)
+	simulatedDurations := make(map[string][]time.Duration)
+	var comparison ReplayComparison
+	for _, pod := range base.GetPodsWithScheduleStatus(PodUnscheduled) {
+		podName := pod.Namespace + "/" + pod.Name
+		realPod, realOk := realPods[podName]
+		simulatedPod, simulatedOk := simulatedPods[podName]
+		realScheduled := realOk && realPod.NodeName != ""
+		simulatedScheduled := simulatedOk && simulatedPod.NodeName != ""
+		if realOk && !realScheduled {
+			comparison.RealUnscheduledPods = append(comparison.RealUnscheduledPods, podName)
+		}
+		if simulatedOk && !simulatedScheduled {
+			comparison.SimulatedUnscheduledPods = append(comparison.SimulatedUnscheduledPods, podName)
+		}
+		if realScheduled {
+			ngName := realNodeGroups[realPod.NodeName]
+			if scheduledAt, ok := realScheduleTimes[podName]; ok {
+				realDurations[ngName] = append(realDurations[ngName], scheduledAt.Sub(realPod.CreationTimestamp))
+			}
+			if c, ok := comparisons[ngName]; ok && simulatedOk && !simulatedScheduled {
+				c.PodsUnscheduledInSimulation = append(c.PodsUnscheduledInSimulation, podName)
+			}
+		}
+		if simulatedScheduled {
+			ngName := simulatedNodeGroups[simulatedPod.NodeName]
+			if scheduledAt, ok := simulatedScheduleTimes[podName]; ok {
+				simulatedDurations[ngName] = append(simulatedDurations[ngName], scheduledAt.Sub(simulatedPod.CreationTimestamp))
+			}
+			if c, ok := comparisons[ngName]; ok && realOk && !realScheduled {
+				c.PodsUnscheduledInReality = append(c.PodsUnscheduledInReality, podName)
+			}
+		}
+	}
+
+	comparison.BaseSnapshotID = base.ID
+	comparison.BaseTime = base.SnapshotTime
+	comparison.RealTime = recorded.SnapshotTime
+	comparison.SimulatedTime = simulated.SnapshotTime
+	names := maps.Keys(comparisons)
+	slices.Sort(names)
+	for _, name := range names {
+		c := comparisons[name]
+		c.ExtraCost = float64(c.SimulatedScaleUps-c.RealScaleUps) * instancePrices[c.InstanceType]
+		c.RealTimeToSchedule = meanDuration(realDurations[name])
+		c.SimulatedTimeToSchedule = meanDuration(simulatedDurations[name])
+		comparison.TotalExtraCost += c.ExtraCost
+		comparison.NodeGroups = append(comparison.NodeGroups, *c)
+	}
+	return comparison
+}
+
+// ToJSON renders the comparison as indented JSON.
+func (c ReplayComparison) ToJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal replay comparison of snapshot %q: %w", c.BaseSnapshotID, err)
+	}
+	return data, nil
+}
+
+// WriteTable renders the comparison as a plain-text table with one row per node group followed by the totals.
+func (c ReplayComparison) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NODE GROUP\tINSTANCE TYPE\tREAL SCALE-UPS\tSIM SCALE-UPS\tUNSCHEDULED REAL\tUNSCHEDULED SIM\tEXTRA COST\tREAL TTS\tSIM TTS\tTTS DIFF")
+	for _, n := range c.NodeGroups {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%.4f\t%s\t%s\t%s\n", n.NodeGroupName, n.InstanceType,
+			n.RealScaleUps, n.SimulatedScaleUps, len(n.PodsUnscheduledInReality), len(n.PodsUnscheduledInSimulation),
+			n.ExtraCost, n.RealTimeToSchedule, n.SimulatedTimeToSchedule, n.GetTimeToScheduleDiff())
+	}
+	_, _ = fmt.Fprintf(tw, "TOTAL\t\t%d\t%d\t%d\t%d\t%.4f\t\t\t\n", c.sumScaleUps(false), c.sumScaleUps(true),
+		len(c.RealUnscheduledPods), len(c.SimulatedUnscheduledPods), c.TotalExtraCost)
+	return tw.Flush()
+}
+
+func (c ReplayComparison) String() string {
+	var sb strings.Builder
+	_ = c.WriteTable(&sb)
+	return sb.String()
+}
+
+func (c ReplayComparison) sumScaleUps(simulated bool) (sum int) {
+	for _, n := range c.NodeGroups {
+		if simulated {
+			sum += n.SimulatedScaleUps
+		} else {
+			sum += n.RealScaleUps
+		}
+	}
+	return
+}
+
+func nodeGroupsByNodeName(resolver *NodeGroupResolver, nodes []NodeInfo) map[string]string {
+	nodeGroups := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		if ng, ok := resolver.Resolve(n); ok {
+			nodeGroups[n.Name] = ng.Name
+		}
+	}
+	return nodeGroups
+}
+
+// scheduleTimesByPodName returns the time of the first Scheduled event per pod namespace and name.
+func scheduleTimesByPodName(events []EventInfo) map[string]time.Time {
+	scheduleTimes := make(map[string]time.Time)
+	for _, e := range events {
+		if e.Reason != EventReasonScheduled || e.InvolvedObjectKind != "Pod" {
+			continue
+		}
+		podName := e.InvolvedObjectNamespace + "/" + e.InvolvedObjectName
+		if t, ok := scheduleTimes[podName]; !ok || e.EventTime.Before(t) {
+			scheduleTimes[podName] = e.EventTime
+		}
+	}
+	return scheduleTimes
+}
+
+// podsByName returns the given pods per namespace and name. Pods of the virtual cluster are recreated from the
+// snapshot and get new UIDs, so only the name identifies the same pod in reality and in simulation.
+func podsByName(pods []PodInfo) map[string]PodInfo {
+	podsByName := make(map[string]PodInfo, len(pods))
+	for _, p := range pods {
+		podsByName[p.Namespace+"/"+p.Name] = p
+	}
+	return podsByName
+}
+
+func meanDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, d := range durations {
+		sum += d
+	}
+	return sum / time.Duration(len(durations))
+}
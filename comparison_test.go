@@ -0,0 +1,160 @@
+package gsc
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+var comparisonTestBaseTime = time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+
+func newComparisonTestNode(name, poolName string) NodeInfo {
+	return NodeInfo{
+		SnapshotMeta: SnapshotMeta{Name: name},
+		Labels:       map[string]string{PoolLabel: poolName, corev1.LabelTopologyZone: "eu-west-1a"},
+	}
+}
+
+func newComparisonTestPod(uid, name, nodeName string) PodInfo {
+	pod := PodInfo{
+		SnapshotMeta: SnapshotMeta{Name: name, Namespace: "shop", CreationTimestamp: comparisonTestBaseTime.Add(-time.Minute)},
+		UID:          uid,
+		NodeName:     nodeName,
+	}
+	if nodeName != "" {
+		pod.PodScheduleStatus = PodScheduleCommited
+	}
+	return pod
+}
+
+func newComparisonTestEvent(name string, offset time.Duration) EventInfo {
+	return EventInfo{
+		Reason:                  EventReasonScheduled,
+		EventTime:               comparisonTestBaseTime.Add(offset),
+		InvolvedObjectKind:      "Pod",
+		InvolvedObjectName:      name,
+		InvolvedObjectNamespace: "shop",
+	}
+}
+
+func TestCompareReplayOutcome(t *testing.T) {
+	base := ClusterSnapshot{
+		ID:           "base",
+		SnapshotTime: comparisonTestBaseTime,
+		AutoscalerConfig: AutoscalerConfig{
+			NodeGroups: map[string]NodeGroupInfo{
+				"ng-a": {Name: "ng-a", PoolName: "a", Zone: "eu-west-1a"},
+				"ng-b": {Name: "ng-b", PoolName: "b", Zone: "eu-west-1a"},
+			},
+			NodeTemplates: map[string]NodeTemplate{
+				"ng-a": {Name: "ng-a", InstanceType: "m5.large"},
+				"ng-b": {Name: "ng-b", InstanceType: "m5.xlarge"},
+			},
+		},
+		Nodes: []NodeInfo{newComparisonTestNode("node-0", "a")},
+		Pods: []PodInfo{
+			newComparisonTestPod("uid-web", "web", "node-0"),
+			newComparisonTestPod("uid-p1", "p1", ""),
+			newComparisonTestPod("uid-p2", "p2", ""),
+		},
+	}
+	recorded := ClusterSnapshot{
+		SnapshotTime: comparisonTestBaseTime.Add(5 * time.Minute),
+		Nodes:        []NodeInfo{newComparisonTestNode("node-0", "a"), newComparisonTestNode("real-1", "a")},
+		Pods: []PodInfo{
+			newComparisonTestPod("uid-web", "web", "node-0"),
+			newComparisonTestPod("uid-p1", "p1", "real-1"),
+			newComparisonTestPod("uid-p2", "p2", ""),
+		},
+	}
+	recordedEvents := []EventInfo{newComparisonTestEvent("p1", 2*time.Minute)}
+	prices := map[string]float64{"m5.large": 0.1, "m5.xlarge": 0.2}
+
+	tests := []struct {
+		name                string
+		simulated           ClusterSnapshot
+		simulatedEvents     []EventInfo
+		wantNodeGroups      []NodeGroupComparison
+		wantRealUnscheduled []string
+		wantSimUnscheduled  []string
+		wantTotalExtraCost  float64
+	}{
+		{
+			name: "simulation schedules all pods with new UIDs",
+			simulated: ClusterSnapshot{
+				SnapshotTime: comparisonTestBaseTime.Add(3 * time.Minute),
+				Nodes:        []NodeInfo{newComparisonTestNode("node-0", "a"), newComparisonTestNode("sim-1", "b"), newComparisonTestNode("sim-2", "b")},
+				Pods: []PodInfo{
+					newComparisonTestPod("sim-uid-web", "web", "node-0"),
+					newComparisonTestPod("sim-uid-p1", "p1", "sim-1"),
+					newComparisonTestPod("sim-uid-p2", "p2", "sim-2"),
+				},
+			},
+			simulatedEvents: []EventInfo{newComparisonTestEvent("p1", time.Minute), newComparisonTestEvent("p2", 90*time.Second)},
+			wantNodeGroups: []NodeGroupComparison{
+				{NodeGroupName: "ng-a", InstanceType: "m5.large", RealScaleUps: 1, ExtraCost: -0.1, RealTimeToSchedule: 3 * time.Minute},
+				{NodeGroupName: "ng-b", InstanceType: "m5.xlarge", SimulatedScaleUps: 2, ExtraCost: 0.4, PodsUnscheduledInReality: []string{"shop/p2"}, SimulatedTimeToSchedule: 135 * time.Second},
+			},
+			wantRealUnscheduled: []string{"shop/p2"},
+			wantTotalExtraCost:  0.3,
+		},
+		{
+			name: "simulation leaves pod with new UID unscheduled",
+			simulated: ClusterSnapshot{
+				SnapshotTime: comparisonTestBaseTime.Add(3 * time.Minute),
+				Nodes:        []NodeInfo{newComparisonTestNode("node-0", "a"), newComparisonTestNode("sim-1", "b")},
+				Pods: []PodInfo{
+					newComparisonTestPod("sim-uid-web", "web", "node-0"),
+					newComparisonTestPod("sim-uid-p1", "p1", "sim-1"),
+					newComparisonTestPod("sim-uid-p2", "p2", ""),
+				},
+			},
+			simulatedEvents: []EventInfo{newComparisonTestEvent("p1", time.Minute)},
+			wantNodeGroups: []NodeGroupComparison{
+				{NodeGroupName: "ng-a", InstanceType: "m5.large", RealScaleUps: 1, ExtraCost: -0.1, RealTimeToSchedule: 3 * time.Minute},
+				{NodeGroupName: "ng-b", InstanceType: "m5.xlarge", SimulatedScaleUps: 1, ExtraCost: 0.2, SimulatedTimeToSchedule: 2 * time.Minute},
+			},
+			wantRealUnscheduled: []string{"shop/p2"},
+			wantSimUnscheduled:  []string{"shop/p2"},
+			wantTotalExtraCost:  0.1,
+		},
+		{
+			name: "simulation leaves pod scheduled in reality unscheduled",
+			simulated: ClusterSnapshot{
+				SnapshotTime: comparisonTestBaseTime.Add(3 * time.Minute),
+				Nodes:        []NodeInfo{newComparisonTestNode("node-0", "a")},
+				Pods: []PodInfo{
+					newComparisonTestPod("sim-uid-web", "web", "node-0"),
+					newComparisonTestPod("sim-uid-p1", "p1", ""),
+				},
+			},
+			wantNodeGroups: []NodeGroupComparison{
+				{NodeGroupName: "ng-a", InstanceType: "m5.large", RealScaleUps: 1, ExtraCost: -0.1, PodsUnscheduledInSimulation: []string{"shop/p1"}, RealTimeToSchedule: 3 * time.Minute},
+				{NodeGroupName: "ng-b", InstanceType: "m5.xlarge"},
+			},
+			wantRealUnscheduled: []string{"shop/p2"},
+			wantSimUnscheduled:  []string{"shop/p1"},
+			wantTotalExtraCost:  -0.1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			comparison := CompareReplayOutcome(base, recorded, tc.simulated, recordedEvents, tc.simulatedEvents, prices)
+			if comparison.BaseSnapshotID != "base" || !comparison.RealTime.Equal(recorded.SnapshotTime) || !comparison.SimulatedTime.Equal(tc.simulated.SnapshotTime) {
+				t.Errorf("unexpected snapshot ID or times of comparison %+v", comparison)
+			}
+			if !reflect.DeepEqual(comparison.NodeGroups, tc.wantNodeGroups) {
+				t.Errorf("expected node groups %+v, got %+v", tc.wantNodeGroups, comparison.NodeGroups)
+			}
+			if !slices.Equal(comparison.RealUnscheduledPods, tc.wantRealUnscheduled) || !slices.Equal(comparison.SimulatedUnscheduledPods, tc.wantSimUnscheduled) {
+				t.Errorf("expected unscheduled pods %v in reality and %v in simulation, got %v and %v", tc.wantRealUnscheduled, tc.wantSimUnscheduled, comparison.RealUnscheduledPods, comparison.SimulatedUnscheduledPods)
+			}
+			if math.Abs(comparison.TotalExtraCost-tc.wantTotalExtraCost) > 1e-9 {
+				t.Errorf("expected total extra cost %f, got %f", tc.wantTotalExtraCost, comparison.TotalExtraCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package controlapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	gsc "github.com/elankath/gardener-scaling-common"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var ErrWaitTimedOut = errors.New("timed out waiting for run completion")
+var ErrRunFailed = errors.New("run failed")
+
+// Client talks to the control API served by a Server.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a Client for the control API at the given base URL such as http://localhost:8080.
+// If httpClient is nil, http.DefaultClient is used.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: baseURL, httpClient: httpClient}
+}
+
+// NewUnixSocketClient creates a Client for the control API served on the unix socket at socketPath.
+func NewUnixSocketClient(socketPath string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return NewClient("http://unix", &http.Client{Transport: transport})
+}
+
+// PushConfig sends the given config to the autoscaler, which starts a run with it.
+func (c *Client) PushConfig(ctx context.Context, config gsc.AutoscalerConfig) (state State, err error) {
+	err = c.do(ctx, http.MethodPut, PathConfig, nil, config, &state)
+	return
+}
+
+// SetMode switches the autoscaler to the given mode.
+func (c *Client) SetMode(ctx context.Context, mode gsc.AutoscalerMode) (state State, err error) {
+	err = c.do(ctx, http.MethodPut, PathMode, nil, modeRequest{Mode: mode}, &state)
+	return
+}
+
+// GetState returns the current config hash, mode and run phase of the autoscaler.
+func (c *Client) GetState(ctx context.Context) (state State, err error) {
+	err = c.do(ctx, http.MethodGet, PathState, nil, nil, &state)
+	return
+}
+
+// WaitForRun long-polls until the run of the config with the given hash is done, re-polling every pollTimeout
+// until the context is done. It returns an error wrapping ErrRunFailed if the run failed.
+func (c *Client) WaitForRun(ctx context.Context, configHash string, pollTimeout time.Duration) (state State, err error) {
+	if pollTimeout <= 0 {
+		pollTimeout = DefaultWaitTimeout
+	}
+	query := url.Values{"hash": {configHash}, "timeout": {pollTimeout.String()}}
+	for {
+		err = c.do(ctx, http.MethodGet, PathWait, query, nil, &state)
+		if errors.Is(err, ErrWaitTimedOut) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if state.Phase == RunPhaseFailed {
+			err = fmt.Errorf("%w: %s", ErrRunFailed, state.Error)
+		}
+		return
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, reqBody any, respBody any) error {
+	var body io.Reader
+	if reqBody != nil {
+		data, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("cannot marshal request for %s %s: %w", method, path, err)
+		}
+		body = bytes.NewReader(data)
+	}
+	reqURL := c.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return fmt.Errorf("cannot create request for %s %s: %w", method, path, err)
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot perform %s %s: %w", method, path, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	switch {
+	case resp.StatusCode == http.StatusRequestTimeout:
+		return ErrWaitTimedOut
+	case resp.StatusCode >= 300:
+		var errResp errorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		return fmt.Errorf("%s %s failed with status %d: %s", method, path, resp.StatusCode, errResp.Error)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(respBody); err != nil {
+		return fmt.Errorf("cannot decode response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
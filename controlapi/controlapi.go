@@ -0,0 +1,45 @@
+package controlapi
+
+import (
+	gsc "github.com/elankath/gardener-scaling-common"
+	"time"
+)
+
+const (
+	PathConfig = "/config"
+	PathMode   = "/mode"
+	PathState  = "/state"
+	PathWait   = "/wait"
+)
+
+// RunPhase is the phase of the run of the current AutoscalerConfig.
+type RunPhase string
+
+const (
+	RunPhaseIdle      RunPhase = "Idle"
+	RunPhaseRunning   RunPhase = "Running"
+	RunPhaseSucceeded RunPhase = "Succeeded"
+	RunPhaseFailed    RunPhase = "Failed"
+)
+
+// State is the control state of the virtual autoscaler as reported by the control API.
+type State struct {
+	ConfigHash string             `json:"configHash"`
+	Mode       gsc.AutoscalerMode `json:"mode"`
+	Phase      RunPhase           `json:"phase"`
+	Error      string             `json:"error,omitempty"`
+	UpdateTime time.Time          `json:"updateTime"`
+}
+
+// IsDone returns true if the run of the current config has either succeeded or failed.
+func (s State) IsDone() bool {
+	return s.Phase == RunPhaseSucceeded || s.Phase == RunPhaseFailed
+}
+
+type modeRequest struct {
+	Mode gsc.AutoscalerMode `json:"mode"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,26 @@
+package controlapi
+
+import (
+	"context"
+	gsc "github.com/elankath/gardener-scaling-common"
+	"time"
+)
+
+// Executor is a gsc.ReplayExecutor that pushes configs to the virtual autoscaler through the control API.
+type Executor struct {
+	Client *Client
+	// PollTimeout is passed to Client.WaitForRun. DefaultWaitTimeout is used if zero or less.
+	PollTimeout time.Duration
+}
+
+var _ gsc.ReplayExecutor = Executor{}
+
+func (e Executor) Apply(ctx context.Context, _ gsc.ClusterSnapshot, config gsc.AutoscalerConfig) error {
+	_, err := e.Client.PushConfig(ctx, config)
+	return err
+}
+
+func (e Executor) WaitForCompletion(ctx context.Context, config gsc.AutoscalerConfig) error {
+	_, err := e.Client.WaitForRun(ctx, config.Hash, e.PollTimeout)
+	return err
+}
@@ -0,0 +1,230 @@
+package controlapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	gsc "github.com/elankath/gardener-scaling-common"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultWaitTimeout is the maximum duration a long-poll on PathWait is held open when no timeout is requested.
+const DefaultWaitTimeout = 30 * time.Second
+
+// Controller is implemented by the virtual autoscaler to act upon requests of the control API.
+type Controller interface {
+	// ApplyConfig starts a run with the given config. The outcome of the run is reported with Server.ReportResult.
+	ApplyConfig(ctx context.Context, config gsc.AutoscalerConfig) error
+	// SetMode switches the autoscaler to the given mode.
+	SetMode(ctx context.Context, mode gsc.AutoscalerMode) error
+}
+
+// Server serves the control API for a Controller. It is an http.Handler that may be mounted on any mux.
+type Server struct {
+	controller Controller
+	modes      *gsc.AutoscalerModeMachine
+	mux        *http.ServeMux
+	mu         sync.Mutex
+	state      State
+	// changed is closed and replaced whenever the state changes, waking up all long-polls.
+	changed chan struct{}
+}
+
+// NewServer creates a Server for the given controller, starting in the given mode. Mode switches requested through
+// the control API are validated against gsc.AllowedModeTransitions.
+func NewServer(controller Controller, mode gsc.AutoscalerMode) (*Server, error) {
+	modes, err := gsc.NewAutoscalerModeMachine(mode)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		controller: controller,
+		modes:      modes,
+		mux:        http.NewServeMux(),
+		state:      State{Mode: mode, Phase: RunPhaseIdle, UpdateTime: time.Now().UTC()},
+		changed:    make(chan struct{}),
+	}
+	modes.OnTransition(func(t gsc.ModeTransition) {
+		s.updateState(func(state *State) bool {
+			state.Mode = t.To
+			return true
+		})
+	})
+	s.mux.HandleFunc("PUT "+PathConfig, s.handleConfig)
+	s.mux.HandleFunc("PUT "+PathMode, s.handleMode)
+	s.mux.HandleFunc("GET "+PathState, s.handleState)
+	s.mux.HandleFunc("GET "+PathWait, s.handleWait)
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ReportResult records the outcome of the run of the config with the given hash. Results for any other than the
+// current config are ignored. A failed run switches to gsc.AutoscalerReplayerErrorMode if allowed from the current mode.
+func (s *Server) ReportResult(configHash string, runErr error) {
+	if runErr != nil {
+		// the config hash is checked while holding the lock of the mode machine, so that a result of a replaced
+		// config never switches modes. Standalone autoscalers have no error mode, so a rejected transition is fine.
+		_ = s.modes.TransitionWith(gsc.AutoscalerReplayerErrorMode, fmt.Sprintf("run of config %q failed: %s", configHash, runErr), func() error {
+			if current := s.GetState().ConfigHash; current != configHash {
+				return fmt.Errorf("cannot report result of config %q while running config %q", configHash, current)
+			}
+			return nil
+		})
+	}
+	s.updateState(func(state *State) bool {
+		if state.ConfigHash != configHash {
+			return false
+		}
+		state.Phase, state.Error = RunPhaseSucceeded, ""
+		if runErr != nil {
+			state.Phase, state.Error = RunPhaseFailed, runErr.Error()
+		}
+		return true
+	})
+}
+
+// GetModeHistory returns the mode transitions done through the control API.
+func (s *Server) GetModeHistory() []gsc.ModeTransition {
+	return s.modes.History()
+}
+
+// GetState returns the current state.
+func (s *Server) GetState() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// ListenAndServeUnix serves the control API on a unix socket at socketPath until the context is done. A stale
+// socket file at socketPath is removed first.
+func (s *Server) ListenAndServeUnix(ctx context.Context, socketPath string) error {
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot remove stale socket %q: %w", socketPath, err)
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("cannot listen on socket %q: %w", socketPath, err)
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves the control API on the given listener until the context is done.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	httpServer := &http.Server{Handler: s, BaseContext: func(net.Listener) context.Context { return ctx }}
+	go func() {
+		<-ctx.Done()
+		_ = httpServer.Close()
+	}()
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) updateState(update func(state *State) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !update(&s.state) {
+		return
+	}
+	s.state.UpdateTime = time.Now().UTC()
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	var config gsc.AutoscalerConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("cannot decode autoscaler config: %w", err))
+		return
+	}
+	if config.Hash == "" {
+		config.Hash = config.GetHash()
+	}
+	s.updateState(func(state *State) bool {
+		state.ConfigHash, state.Phase, state.Error = config.Hash, RunPhaseRunning, ""
+		return true
+	})
+	if err := s.controller.ApplyConfig(r.Context(), config); err != nil {
+		s.ReportResult(config.Hash, err)
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, s.GetState())
+}
+
+func (s *Server) handleMode(w http.ResponseWriter, r *http.Request) {
+	var req modeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("cannot decode mode request: %w", err))
+		return
+	}
+	mode, err := gsc.ParseAutoscalerMode(string(req.Mode))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	err = s.modes.TransitionWith(mode, "control api request", func() error {
+		return s.controller.SetMode(r.Context(), mode)
+	})
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.GetState())
+}
+
+func (s *Server) handleState(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.GetState())
+}
+
+// handleWait long-polls until the run of the config with the hash given by the query parameter is done or the
+// timeout given by the query parameter expires. It answers 408 if the run is not done in time.
+func (s *Server) handleWait(w http.ResponseWriter, r *http.Request) {
+	hash := r.URL.Query().Get("hash")
+	timeout := DefaultWaitTimeout
+	if t := r.URL.Query().Get("timeout"); t != "" {
+		var err error
+		if timeout, err = time.ParseDuration(t); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("cannot parse timeout %q: %w", t, err))
+			return
+		}
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		s.mu.Lock()
+		state, changed := s.state, s.changed
+		s.mu.Unlock()
+		if (hash == "" || state.ConfigHash == hash) && state.IsDone() {
+			writeJSON(w, http.StatusOK, state)
+			return
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			writeJSON(w, http.StatusRequestTimeout, state)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
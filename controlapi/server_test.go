@@ -0,0 +1,211 @@
+package controlapi
+
+import (
+	"context"
+	"errors"
+	gsc "github.com/elankath/gardener-scaling-common"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeController struct {
+	mu      sync.Mutex
+	configs []gsc.AutoscalerConfig
+	modes   []gsc.AutoscalerMode
+}
+
+func (c *fakeController) ApplyConfig(_ context.Context, config gsc.AutoscalerConfig) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.configs = append(c.configs, config)
+	return nil
+}
+
+func (c *fakeController) SetMode(_ context.Context, mode gsc.AutoscalerMode) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.modes = append(c.modes, mode)
+	return nil
+}
+
+// getApplied returns copies of the configs and modes applied so far.
+func (c *fakeController) getApplied() ([]gsc.AutoscalerConfig, []gsc.AutoscalerMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.configs), slices.Clone(c.modes)
+}
+
+// startUnixSocketServer serves a new Server on a unix socket until the test ends and returns a client for it.
+func startUnixSocketServer(t *testing.T, controller Controller, mode gsc.AutoscalerMode) (*Server, *Client) {
+	t.Helper()
+	server, err := NewServer(controller, mode)
+	if err != nil {
+		t.Fatalf("cannot create server: %v", err)
+	}
+	// unix socket paths are limited in length, so t.TempDir may be too long.
+	dir, err := os.MkdirTemp("", "controlapi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	socketPath := filepath.Join(dir, "control.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.ListenAndServeUnix(ctx, socketPath) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("cannot serve control api: %v", err)
+		}
+		_ = os.RemoveAll(dir)
+	})
+	client := NewUnixSocketClient(socketPath)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err = client.GetState(context.Background()); err == nil {
+			return server, client
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("control api did not come up: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPushConfigReportResultWaitForRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		runErr    error
+		wantPhase RunPhase
+		wantMode  gsc.AutoscalerMode
+	}{
+		{"succeeded", nil, RunPhaseSucceeded, gsc.AutoscalerReplayerRunMode},
+		{"failed", errors.New("node group not found"), RunPhaseFailed, gsc.AutoscalerReplayerErrorMode},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			controller := &fakeController{}
+			server, client := startUnixSocketServer(t, controller, gsc.AutoscalerReplayerRunMode)
+			ctx := context.Background()
+			config := gsc.AutoscalerConfig{Mode: gsc.AutoscalerReplayerRunMode}
+			config.Hash = config.GetHash()
+
+			state, err := client.PushConfig(ctx, config)
+			if err != nil {
+				t.Fatalf("cannot push config: %v", err)
+			}
+			if state.ConfigHash != config.Hash || state.Phase != RunPhaseRunning {
+				t.Fatalf("expected running config %q, got %+v", config.Hash, state)
+			}
+			if configs, _ := controller.getApplied(); len(configs) != 1 || configs[0].Hash != config.Hash {
+				t.Fatalf("expected config to be applied once, got %d configs", len(configs))
+			}
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				server.ReportResult(config.Hash, tc.runErr)
+			}()
+
+			state, err = client.WaitForRun(ctx, config.Hash, time.Second)
+			if tc.runErr == nil && err != nil {
+				t.Fatalf("cannot wait for run: %v", err)
+			}
+			if tc.runErr != nil && (!errors.Is(err, ErrRunFailed) || !strings.Contains(err.Error(), tc.runErr.Error())) {
+				t.Fatalf("expected ErrRunFailed with %q, got %v", tc.runErr, err)
+			}
+			if state.Phase != tc.wantPhase || state.Mode != tc.wantMode {
+				t.Errorf("expected phase %s in mode %s, got %+v", tc.wantPhase, tc.wantMode, state)
+			}
+		})
+	}
+}
+
+func TestReportResultIgnoresReplacedConfig(t *testing.T) {
+	server, client := startUnixSocketServer(t, &fakeController{}, gsc.AutoscalerReplayerRunMode)
+	ctx := context.Background()
+	first := gsc.AutoscalerConfig{Mode: gsc.AutoscalerReplayerRunMode, Hash: "first"}
+	second := gsc.AutoscalerConfig{Mode: gsc.AutoscalerReplayerRunMode, Hash: "second"}
+	for _, config := range []gsc.AutoscalerConfig{first, second} {
+		if _, err := client.PushConfig(ctx, config); err != nil {
+			t.Fatalf("cannot push config: %v", err)
+		}
+	}
+
+	server.ReportResult(first.Hash, errors.New("node group not found"))
+	state := server.GetState()
+	if state.ConfigHash != second.Hash || state.Phase != RunPhaseRunning || state.Mode != gsc.AutoscalerReplayerRunMode {
+		t.Errorf("expected config %q to keep running in mode %s, got %+v", second.Hash, gsc.AutoscalerReplayerRunMode, state)
+	}
+	if history := server.GetModeHistory(); len(history) != 0 {
+		t.Errorf("expected no mode transitions, got %v", history)
+	}
+}
+
+func TestSetModeRejectsInvalidTransition(t *testing.T) {
+	controller := &fakeController{}
+	server, client := startUnixSocketServer(t, controller, gsc.AutoscalerStandaloneMode)
+
+	_, err := client.SetMode(context.Background(), gsc.AutoscalerReplayerRunMode)
+	if err == nil || !strings.Contains(err.Error(), "status 409") {
+		t.Fatalf("expected transition to be rejected with 409, got %v", err)
+	}
+	if _, modes := controller.getApplied(); len(modes) != 0 {
+		t.Errorf("expected controller not to switch mode, got %v", modes)
+	}
+	if mode := server.GetState().Mode; mode != gsc.AutoscalerStandaloneMode {
+		t.Errorf("expected mode to stay %s, got %s", gsc.AutoscalerStandaloneMode, mode)
+	}
+}
+
+func TestWaitForRunRePollsAfterTimeout(t *testing.T) {
+	server, err := NewServer(&fakeController{}, gsc.AutoscalerReplayerRunMode)
+	if err != nil {
+		t.Fatalf("cannot create server: %v", err)
+	}
+	var waits atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == PathWait {
+			waits.Add(1)
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+	client := NewClient(httpServer.URL, httpServer.Client())
+	ctx := context.Background()
+	config := gsc.AutoscalerConfig{Mode: gsc.AutoscalerReplayerRunMode}
+	config.Hash = config.GetHash()
+	if _, err = client.PushConfig(ctx, config); err != nil {
+		t.Fatalf("cannot push config: %v", err)
+	}
+
+	resp, err := httpServer.Client().Get(httpServer.URL + PathWait + "?hash=" + config.Hash + "&timeout=10ms")
+	if err != nil {
+		t.Fatalf("cannot long-poll: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Fatalf("expected long-poll to time out with 408, got %d", resp.StatusCode)
+	}
+
+	waits.Store(0)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		server.ReportResult(config.Hash, nil)
+	}()
+	state, err := client.WaitForRun(ctx, config.Hash, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("cannot wait for run: %v", err)
+	}
+	if state.Phase != RunPhaseSucceeded {
+		t.Errorf("expected run to succeed, got %+v", state)
+	}
+	if n := waits.Load(); n < 2 {
+		t.Errorf("expected WaitForRun to re-poll after timed out long-polls, polled %d times", n)
+	}
+}
@@ -0,0 +1,237 @@
+package gsc
+
+import (
+	"golang.org/x/exp/maps"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"slices"
+	"time"
+)
+
+// AsPodInfo converts the given pod into a PodInfo captured at snapshotTime, including its Hash. The spec is deep
+// copied since computing the hash sorts its slices, which must not mutate pods shared with informer caches.
+func AsPodInfo(pod *corev1.Pod, snapshotTime time.Time) PodInfo {
+	p := PodInfo{
+		SnapshotMeta: SnapshotMeta{
+			CreationTimestamp: pod.CreationTimestamp.UTC(),
+			SnapshotTimestamp: snapshotTime.UTC(),
+			Name:              pod.Name,
+			Namespace:         pod.Namespace,
+		},
+		UID:               string(pod.UID),
+		NodeName:          pod.Spec.NodeName,
+		NominatedNodeName: pod.Status.NominatedNodeName,
+		Labels:            pod.Labels,
+		Requests:          CumulatePodRequests(pod),
+		Spec:              *pod.Spec.DeepCopy(),
+		PodScheduleStatus: ComputePodScheduleStatus(pod),
+		PodPhase:          pod.Status.Phase,
+	}
+	if pod.DeletionTimestamp != nil {
+		p.DeletionTimestamp = pod.DeletionTimestamp.UTC()
+	}
+	p.Hash = p.GetHash()
+	return p
+}
+
+// ComputePodScheduleStatus derives the PodScheduleStatus from the node name, nominated node name and the
+// PodScheduled condition of the given pod.
+func ComputePodScheduleStatus(pod *corev1.Pod) PodScheduleStatus {
+	if pod.Spec.NodeName != "" {
+		return PodScheduleCommited
+	}
+	if pod.Status.NominatedNodeName != "" {
+		return PodScheduleNominated
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason == corev1.PodReasonUnschedulable {
+			return PodUnscheduled
+		}
+	}
+	return PodSchedulePending
+}
+
+// AsNodeInfo converts the given node into a NodeInfo captured at snapshotTime, including its Hash.
+func AsNodeInfo(node *corev1.Node, snapshotTime time.Time) NodeInfo {
+	n := NodeInfo{
+		SnapshotMeta: SnapshotMeta{
+			CreationTimestamp: node.CreationTimestamp.UTC(),
+			SnapshotTimestamp: snapshotTime.UTC(),
+			Name:              node.Name,
+			Namespace:         node.Namespace,
+		},
+		ProviderID:  node.Spec.ProviderID,
+		Labels:      node.Labels,
+		Taints:      node.Spec.Taints,
+		Allocatable: node.Status.Allocatable,
+		Capacity:    node.Status.Capacity,
+	}
+	if node.DeletionTimestamp != nil {
+		n.DeletionTimestamp = node.DeletionTimestamp.UTC()
+	}
+	n.Hash = n.GetHash()
+	return n
+}
+
+// AsPriorityClassInfo converts the given priority class into a PriorityClassInfo captured at snapshotTime, including its Hash.
+func AsPriorityClassInfo(pc *schedulingv1.PriorityClass, snapshotTime time.Time) PriorityClassInfo {
+	p := PriorityClassInfo{
+		SnapshotTimestamp: snapshotTime.UTC(),
+		PriorityClass:     *pc,
+	}
+	p.Hash = p.GetHash()
+	return p
+}
+
+// AsEventInfo converts the given core/v1 event into an EventInfo. The EventTime is the first non-zero value of the
+// event's EventTime, LastTimestamp, FirstTimestamp and CreationTimestamp.
+func AsEventInfo(event *corev1.Event) EventInfo {
+	reportingController := event.ReportingController
+	if reportingController == "" {
+		reportingController = event.Source.Component
+	}
+	e := EventInfo{
+		UID:                     string(event.UID),
+		EventTime:               firstNonZeroTime(event.EventTime.Time, event.LastTimestamp.Time, event.FirstTimestamp.Time, event.CreationTimestamp.Time).UTC(),
+		ReportingController:     reportingController,
+		Reason:                  event.Reason,
+		Message:                 event.Message,
+		InvolvedObjectKind:      event.InvolvedObject.Kind,
+		InvolvedObjectName:      event.InvolvedObject.Name,
+		InvolvedObjectNamespace: event.InvolvedObject.Namespace,
+		InvolvedObjectUID:       string(event.InvolvedObject.UID),
+		Type:                    event.Type,
+		Action:                  event.Action,
+		Count:                   event.Count,
+		FirstTimestamp:          event.FirstTimestamp.UTC(),
+		LastTimestamp:           event.LastTimestamp.UTC(),
+	}
+	if event.Series != nil {
+		e.SeriesCount = event.Series.Count
+		e.SeriesLastObservedTime = event.Series.LastObservedTime.UTC()
+	}
+	return e
+}
+
+// AsEventInfoFromEventsV1 converts the given events.k8s.io/v1 event into an EventInfo. The Note of the event is
+// stored as the Message and the Regarding object as the involved object. The EventTime is the first non-zero
+// value of the event's EventTime, DeprecatedLastTimestamp, DeprecatedFirstTimestamp and CreationTimestamp.
+func AsEventInfoFromEventsV1(event *eventsv1.Event) EventInfo {
+	reportingController := event.ReportingController
+	if reportingController == "" {
+		reportingController = event.DeprecatedSource.Component
+	}
+	e := EventInfo{
+		UID:                     string(event.UID),
+		EventTime:               firstNonZeroTime(event.EventTime.Time, event.DeprecatedLastTimestamp.Time, event.DeprecatedFirstTimestamp.Time, event.CreationTimestamp.Time).UTC(),
+		ReportingController:     reportingController,
+		Reason:                  event.Reason,
+		Message:                 event.Note,
+		InvolvedObjectKind:      event.Regarding.Kind,
+		InvolvedObjectName:      event.Regarding.Name,
+		InvolvedObjectNamespace: event.Regarding.Namespace,
+		InvolvedObjectUID:       string(event.Regarding.UID),
+		Type:                    event.Type,
+		Action:                  event.Action,
+		Count:                   event.DeprecatedCount,
+		FirstTimestamp:          event.DeprecatedFirstTimestamp.UTC(),
+		LastTimestamp:           event.DeprecatedLastTimestamp.UTC(),
+	}
+	if event.Series != nil {
+		e.SeriesCount = event.Series.Count
+		e.SeriesLastObservedTime = event.Series.LastObservedTime.UTC()
+	}
+	return e
+}
+
+func firstNonZeroTime(times ...time.Time) time.Time {
+	for _, t := range times {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// AsPod converts the given PodInfo back into a pod stripped to the fields relevant for scheduling. Containers only
+// keep their name, image and resources, and volumes and service account tokens are dropped so that the pod can be
+// created in a cluster lacking the referenced objects. The Priority is left unset to be computed from the
+// PriorityClassName by admission.
+func AsPod(podInfo PodInfo) *corev1.Pod {
+	automountServiceAccountToken := false
+	spec := podInfo.Spec
+	return &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podInfo.Name,
+			Namespace: podInfo.Namespace,
+			Labels:    maps.Clone(podInfo.Labels),
+		},
+		Spec: corev1.PodSpec{
+			InitContainers:               stripContainers(spec.InitContainers),
+			Containers:                   stripContainers(spec.Containers),
+			NodeName:                     podInfo.NodeName,
+			NodeSelector:                 maps.Clone(spec.NodeSelector),
+			Affinity:                     spec.Affinity.DeepCopy(),
+			Tolerations:                  slices.Clone(spec.Tolerations),
+			TopologySpreadConstraints:    slices.Clone(spec.TopologySpreadConstraints),
+			PriorityClassName:            spec.PriorityClassName,
+			PreemptionPolicy:             spec.PreemptionPolicy,
+			SchedulerName:                spec.SchedulerName,
+			Overhead:                     spec.Overhead.DeepCopy(),
+			AutomountServiceAccountToken: &automountServiceAccountToken,
+		},
+	}
+}
+
+func stripContainers(containers []corev1.Container) []corev1.Container {
+	if len(containers) == 0 {
+		return nil
+	}
+	stripped := make([]corev1.Container, 0, len(containers))
+	for _, c := range containers {
+		stripped = append(stripped, corev1.Container{
+			Name:      c.Name,
+			Image:     c.Image,
+			Resources: *c.Resources.DeepCopy(),
+		})
+	}
+	return stripped
+}
+
+// AsNode converts the given NodeInfo back into a node with its labels, taints, provider ID, capacity and allocatable.
+func AsNode(nodeInfo NodeInfo) *corev1.Node {
+	return &corev1.Node{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Node"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   nodeInfo.Name,
+			Labels: maps.Clone(nodeInfo.Labels),
+		},
+		Spec: corev1.NodeSpec{
+			ProviderID: nodeInfo.ProviderID,
+			Taints:     slices.Clone(nodeInfo.Taints),
+		},
+		Status: corev1.NodeStatus{
+			Capacity:    nodeInfo.Capacity.DeepCopy(),
+			Allocatable: nodeInfo.Allocatable.DeepCopy(),
+		},
+	}
+}
+
+// AsPriorityClass converts the given PriorityClassInfo back into a priority class without server-populated metadata.
+func AsPriorityClass(pcInfo PriorityClassInfo) *schedulingv1.PriorityClass {
+	return &schedulingv1.PriorityClass{
+		TypeMeta: metav1.TypeMeta{APIVersion: "scheduling.k8s.io/v1", Kind: "PriorityClass"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pcInfo.Name,
+			Labels:      maps.Clone(pcInfo.Labels),
+			Annotations: maps.Clone(pcInfo.Annotations),
+		},
+		Value:            pcInfo.Value,
+		GlobalDefault:    pcInfo.GlobalDefault,
+		Description:      pcInfo.Description,
+		PreemptionPolicy: pcInfo.PreemptionPolicy,
+	}
+}
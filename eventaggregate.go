@@ -0,0 +1,96 @@
+package gsc
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"slices"
+	"time"
+)
+
+// EventAggregate collapses repeated events of the same reason about the same involved object that occurred within
+// a time window of each other.
+type EventAggregate struct {
+	InvolvedObjectKind      string
+	InvolvedObjectName      string
+	InvolvedObjectNamespace string
+	InvolvedObjectUID       string
+	Reason                  string
+	Type                    string
+	// LastMessage is the message of the most recent event of the aggregate.
+	LastMessage string
+	// Occurrences is the sum of the GetOccurrences of all events of the aggregate.
+	Occurrences int
+	FirstTime   time.Time
+	LastTime    time.Time
+	Events      []EventInfo
+}
+
+func (a EventAggregate) String() string {
+	return fmt.Sprintf("EventAggregate(InvolvedObject=%s/%s/%s, Reason=%s, Type=%s, Occurrences=%d, FirstTime=%s, LastTime=%s, LastMessage=%s)",
+		a.InvolvedObjectKind, a.InvolvedObjectNamespace, a.InvolvedObjectName, a.Reason, a.Type, a.Occurrences, a.FirstTime, a.LastTime, a.LastMessage)
+}
+
+// GetOccurrences returns how often the event occurred, taking the event series count and the deprecated count into account.
+func (eI EventInfo) GetOccurrences() int {
+	return max(int(eI.SeriesCount), int(eI.Count), 1)
+}
+
+// GetFirstTime returns the time the event was first observed.
+func (eI EventInfo) GetFirstTime() time.Time {
+	return firstNonZeroTime(eI.FirstTimestamp, eI.EventTime)
+}
+
+// GetLastTime returns the time the event was last observed.
+func (eI EventInfo) GetLastTime() time.Time {
+	return firstNonZeroTime(eI.SeriesLastObservedTime, eI.LastTimestamp, eI.EventTime)
+}
+
+// AggregateEvents groups the given events by involved object and reason and collapses every group into
+// aggregates: an event whose first time is more than window after the last time of the current aggregate
+// starts a new aggregate. A window of zero or less collapses every group into a single aggregate. The result is
+// sorted by FirstTime.
+func AggregateEvents(events []EventInfo, window time.Duration) []EventAggregate {
+	groups := lo.GroupBy(events, func(e EventInfo) string {
+		involvedObject := e.InvolvedObjectUID
+		if involvedObject == "" {
+			involvedObject = e.InvolvedObjectKind + "/" + e.InvolvedObjectNamespace + "/" + e.InvolvedObjectName
+		}
+		return involvedObject + "|" + e.Reason
+	})
+	var aggregates []EventAggregate
+	for _, group := range groups {
+		slices.SortFunc(group, func(a, b EventInfo) int {
+			return a.GetFirstTime().Compare(b.GetFirstTime())
+		})
+		var current *EventAggregate
+		for _, e := range group {
+			if current == nil || (window > 0 && e.GetFirstTime().Sub(current.LastTime) > window) {
+				if current != nil {
+					aggregates = append(aggregates, *current)
+				}
+				current = &EventAggregate{
+					InvolvedObjectKind:      e.InvolvedObjectKind,
+					InvolvedObjectName:      e.InvolvedObjectName,
+					InvolvedObjectNamespace: e.InvolvedObjectNamespace,
+					InvolvedObjectUID:       e.InvolvedObjectUID,
+					Reason:                  e.Reason,
+					FirstTime:               e.GetFirstTime(),
+				}
+			}
+			current.Type = e.Type
+			current.LastMessage = e.Message
+			current.Occurrences += e.GetOccurrences()
+			if lastTime := e.GetLastTime(); lastTime.After(current.LastTime) {
+				current.LastTime = lastTime
+			}
+			current.Events = append(current.Events, e)
+		}
+		if current != nil {
+			aggregates = append(aggregates, *current)
+		}
+	}
+	slices.SortFunc(aggregates, func(a, b EventAggregate) int {
+		return a.FirstTime.Compare(b.FirstTime)
+	})
+	return aggregates
+}
@@ -0,0 +1,232 @@
+package gsc
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EventReasonTriggeredScaleUp  = "TriggeredScaleUp"
+	EventReasonNotTriggerScaleUp = "NotTriggerScaleUp"
+	EventReasonScaleDown         = "ScaleDown"
+	EventReasonFailedScheduling  = "FailedScheduling"
+	EventReasonScaleUpTimedOut   = "ScaleUpTimedOut"
+)
+
+// ParsableEventReasons are the event reasons understood by ParseEvent.
+var ParsableEventReasons = []string{
+	EventReasonTriggeredScaleUp, EventReasonNotTriggerScaleUp, EventReasonScaleDown, EventReasonFailedScheduling, EventReasonScaleUpTimedOut,
+}
+
+var ErrUnsupportedEventReason = errors.New("unsupported event reason")
+var ErrUnexpectedEventMessage = errors.New("unexpected event message")
+
+// ParsedEvent is implemented by all typed events returned from ParseEvent.
+type ParsedEvent interface {
+	GetEventInfo() EventInfo
+}
+
+// PredicateFailure is a single entry of a comma separated failure summary such as "3 Insufficient cpu".
+type PredicateFailure struct {
+	Count  int
+	Reason string
+}
+
+// NodeGroupScaleUp is a single node group size change of a TriggeredScaleUp event.
+type NodeGroupScaleUp struct {
+	NodeGroupName string
+	CurrentSize   int
+	NewSize       int
+	MaxSize       int
+}
+
+// TriggeredScaleUpEvent is emitted by the cluster-autoscaler on a pod that caused a scale-up.
+type TriggeredScaleUpEvent struct {
+	EventInfo EventInfo
+	ScaleUps  []NodeGroupScaleUp
+}
+
+// NotTriggerScaleUpEvent is emitted by the cluster-autoscaler on a pod for which no node group could be scaled up.
+type NotTriggerScaleUpEvent struct {
+	EventInfo EventInfo
+	// WouldNotFitOnNewNode is true if the message states that the pod would not fit even if a new node were added.
+	WouldNotFitOnNewNode bool
+	Failures             []PredicateFailure
+}
+
+// ScaleDownEvent is emitted by the cluster-autoscaler on nodes being removed and pods being evicted from them.
+type ScaleDownEvent struct {
+	EventInfo EventInfo
+	// NodeName is the name of the node being removed, if mentioned in the message.
+	NodeName string
+	// EmptyNode is true if the removed node had no pods to reschedule.
+	EmptyNode bool
+}
+
+// FailedSchedulingEvent is emitted by the kube-scheduler on a pod that could not be scheduled.
+type FailedSchedulingEvent struct {
+	EventInfo          EventInfo
+	AvailableNodes     int
+	TotalNodes         int
+	Failures           []PredicateFailure
+	PreemptionFailures []PredicateFailure
+}
+
+// ScaleUpTimedOutEvent is emitted by the cluster-autoscaler when nodes of a scale-up did not register in time.
+type ScaleUpTimedOutEvent struct {
+	EventInfo     EventInfo
+	NodeGroupName string
+	Timeout       time.Duration
+}
+
+func (e TriggeredScaleUpEvent) GetEventInfo() EventInfo  { return e.EventInfo }
+func (e NotTriggerScaleUpEvent) GetEventInfo() EventInfo { return e.EventInfo }
+func (e ScaleDownEvent) GetEventInfo() EventInfo         { return e.EventInfo }
+func (e FailedSchedulingEvent) GetEventInfo() EventInfo  { return e.EventInfo }
+func (e ScaleUpTimedOutEvent) GetEventInfo() EventInfo   { return e.EventInfo }
+
+var (
+	scaleUpRegex          = regexp.MustCompile(`\{(\S+) (\d+)->(\d+) \(max: (\d+)\)}`)
+	nodesAvailableRegex   = regexp.MustCompile(`^(\d+)/(\d+) nodes are available`)
+	scaleDownNodeRegex    = regexp.MustCompile(`(?:removing (empty )?node|(empty )?node) "?([^",\s]+)"?`)
+	scaleUpTimedOutRegex  = regexp.MustCompile(`^Nodes added to group (\S+) failed to register within (\S+?)\.?$`)
+	predicateFailureRegex = regexp.MustCompile(`^(\d+) (.+)$`)
+)
+
+// ParseEvent parses the message of the given event according to its Reason and returns one of
+// TriggeredScaleUpEvent, NotTriggerScaleUpEvent, ScaleDownEvent, FailedSchedulingEvent or ScaleUpTimedOutEvent.
+func ParseEvent(e EventInfo) (ParsedEvent, error) {
+	switch e.Reason {
+	case EventReasonTriggeredScaleUp:
+		return ParseTriggeredScaleUp(e)
+	case EventReasonNotTriggerScaleUp:
+		return ParseNotTriggerScaleUp(e)
+	case EventReasonScaleDown:
+		return ParseScaleDown(e)
+	case EventReasonFailedScheduling:
+		return ParseFailedScheduling(e)
+	case EventReasonScaleUpTimedOut:
+		return ParseScaleUpTimedOut(e)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedEventReason, e.Reason)
+}
+
+// ParseTriggeredScaleUp parses messages like "pod triggered scale-up: [{shoot--p--c-a-z1 1->2 (max: 5)}]".
+func ParseTriggeredScaleUp(e EventInfo) (parsed TriggeredScaleUpEvent, err error) {
+	parsed.EventInfo = e
+	for _, m := range scaleUpRegex.FindAllStringSubmatch(e.Message, -1) {
+		scaleUp := NodeGroupScaleUp{NodeGroupName: m[1]}
+		scaleUp.CurrentSize, _ = strconv.Atoi(m[2])
+		scaleUp.NewSize, _ = strconv.Atoi(m[3])
+		scaleUp.MaxSize, _ = strconv.Atoi(m[4])
+		parsed.ScaleUps = append(parsed.ScaleUps, scaleUp)
+	}
+	if len(parsed.ScaleUps) == 0 {
+		err = fmt.Errorf("%w: no node group size changes in %s message %q", ErrUnexpectedEventMessage, e.Reason, e.Message)
+	}
+	return
+}
+
+// ParseNotTriggerScaleUp parses messages like "pod didn't trigger scale-up: 3 max node group size reached, 2
+// node(s) didn't match Pod's node affinity/selector".
+func ParseNotTriggerScaleUp(e EventInfo) (parsed NotTriggerScaleUpEvent, err error) {
+	parsed.EventInfo = e
+	prefix, summary, found := strings.Cut(e.Message, ": ")
+	if !found {
+		err = fmt.Errorf("%w: no failure summary in %s message %q", ErrUnexpectedEventMessage, e.Reason, e.Message)
+		return
+	}
+	parsed.WouldNotFitOnNewNode = strings.Contains(prefix, "wouldn't fit if a new node is added")
+	parsed.Failures = ParsePredicateFailures(summary)
+	return
+}
+
+// ParseScaleDown parses messages like `Scale-down: removing empty node "node-1"`, "Scale-down: empty node node-1
+// removed", "Scale-down: removing node node-1, utilization: ..." or "deleting pod for node scale down". The node name is left empty if the message does not mention one.
+func ParseScaleDown(e EventInfo) (parsed ScaleDownEvent, err error) {
+	parsed.EventInfo = e
+	if e.InvolvedObjectKind == "Node" {
+		parsed.NodeName = e.InvolvedObjectName
+	}
+	if m := scaleDownNodeRegex.FindStringSubmatch(e.Message); m != nil && strings.HasPrefix(e.Message, "Scale-down:") {
+		parsed.EmptyNode = m[1] != "" || m[2] != ""
+		parsed.NodeName = m[3]
+	}
+	return
+}
+
+// ParseFailedScheduling parses messages like "0/5 nodes are available: 3 Insufficient cpu, 2 node(s) didn't match
+// Pod's node affinity/selector. preemption: 0/5 nodes are available: 5 No preemption victims found for incoming pod."
+func ParseFailedScheduling(e EventInfo) (parsed FailedSchedulingEvent, err error) {
+	parsed.EventInfo = e
+	m := nodesAvailableRegex.FindStringSubmatch(e.Message)
+	if m == nil {
+		err = fmt.Errorf("%w: no node availability in %s message %q", ErrUnexpectedEventMessage, e.Reason, e.Message)
+		return
+	}
+	parsed.AvailableNodes, _ = strconv.Atoi(m[1])
+	parsed.TotalNodes, _ = strconv.Atoi(m[2])
+	schedulingMsg, preemptionMsg, _ := strings.Cut(e.Message, " preemption: ")
+	if _, summary, found := strings.Cut(schedulingMsg, ": "); found {
+		parsed.Failures = ParsePredicateFailures(summary)
+	}
+	if _, summary, found := strings.Cut(preemptionMsg, ": "); found {
+		parsed.PreemptionFailures = ParsePredicateFailures(summary)
+	}
+	return
+}
+
+// ParseScaleUpTimedOut parses messages like "Nodes added to group shoot--p--c-a-z1 failed to register within 15m0s".
+func ParseScaleUpTimedOut(e EventInfo) (parsed ScaleUpTimedOutEvent, err error) {
+	parsed.EventInfo = e
+	m := scaleUpTimedOutRegex.FindStringSubmatch(strings.TrimSpace(e.Message))
+	if m == nil {
+		err = fmt.Errorf("%w: no node group in %s message %q", ErrUnexpectedEventMessage, e.Reason, e.Message)
+		return
+	}
+	parsed.NodeGroupName = m[1]
+	parsed.Timeout, err = time.ParseDuration(m[2])
+	if err != nil {
+		err = fmt.Errorf("cannot parse timeout of %s message %q: %w", e.Reason, e.Message, err)
+	}
+	return
+}
+
+// ParsePredicateFailures parses a comma separated summary like "3 Insufficient cpu, 2 node(s) had untolerated
+// taint {foo: bar}." into its counted failures. Entries without a leading count are ignored.
+func ParsePredicateFailures(summary string) []PredicateFailure {
+	var failures []PredicateFailure
+	for _, part := range splitOutsideBraces(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(summary), "."))) {
+		m := predicateFailureRegex.FindStringSubmatch(strings.TrimSpace(part))
+		if m == nil {
+			continue
+		}
+		count, _ := strconv.Atoi(m[1])
+		failures = append(failures, PredicateFailure{Count: count, Reason: m[2]})
+	}
+	return failures
+}
+
+// splitOutsideBraces splits s on commas that are not enclosed in braces, since taints are rendered as {key: value}.
+func splitOutsideBraces(s string) []string {
+	var parts []string
+	var depth, start int
+	for i, r := range s {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,147 @@
+package gsc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   EventInfo
+		want    ParsedEvent
+		wantErr error
+	}{
+		{
+			name:  "triggered scale-up of multiple node groups",
+			event: EventInfo{Reason: EventReasonTriggeredScaleUp, Message: "pod triggered scale-up: [{shoot--garden--aws-worker-a-z1 1->3 (max: 5)} {shoot--garden--aws-worker-a-z2 0->1 (max: 5)}]"},
+			want: TriggeredScaleUpEvent{ScaleUps: []NodeGroupScaleUp{
+				{NodeGroupName: "shoot--garden--aws-worker-a-z1", CurrentSize: 1, NewSize: 3, MaxSize: 5},
+				{NodeGroupName: "shoot--garden--aws-worker-a-z2", CurrentSize: 0, NewSize: 1, MaxSize: 5},
+			}},
+		},
+		{
+			name:    "triggered scale-up without node groups",
+			event:   EventInfo{Reason: EventReasonTriggeredScaleUp, Message: "pod triggered scale-up: []"},
+			want:    TriggeredScaleUpEvent{},
+			wantErr: ErrUnexpectedEventMessage,
+		},
+		{
+			name:  "not triggered scale-up",
+			event: EventInfo{Reason: EventReasonNotTriggerScaleUp, Message: "pod didn't trigger scale-up: 1 max node group size reached, 2 node(s) didn't match Pod's node affinity/selector"},
+			want: NotTriggerScaleUpEvent{Failures: []PredicateFailure{
+				{Count: 1, Reason: "max node group size reached"},
+				{Count: 2, Reason: "node(s) didn't match Pod's node affinity/selector"},
+			}},
+		},
+		{
+			name:  "not triggered scale-up since pod would not fit",
+			event: EventInfo{Reason: EventReasonNotTriggerScaleUp, Message: "pod didn't trigger scale-up (it wouldn't fit if a new node is added): 3 Insufficient memory, 1 node(s) had untolerated taint {node.kubernetes.io/unreachable: }"},
+			want: NotTriggerScaleUpEvent{WouldNotFitOnNewNode: true, Failures: []PredicateFailure{
+				{Count: 3, Reason: "Insufficient memory"},
+				{Count: 1, Reason: "node(s) had untolerated taint {node.kubernetes.io/unreachable: }"},
+			}},
+		},
+		{
+			name:  "scale-down removing empty node",
+			event: EventInfo{Reason: EventReasonScaleDown, Message: `Scale-down: removing empty node "shoot--garden--aws-worker-a-z1-5b8c9-xk2lp"`},
+			want:  ScaleDownEvent{NodeName: "shoot--garden--aws-worker-a-z1-5b8c9-xk2lp", EmptyNode: true},
+		},
+		{
+			name:  "scale-down empty node removed",
+			event: EventInfo{Reason: EventReasonScaleDown, Message: "Scale-down: empty node shoot--garden--aws-worker-a-z1-5b8c9-xk2lp removed"},
+			want:  ScaleDownEvent{NodeName: "shoot--garden--aws-worker-a-z1-5b8c9-xk2lp", EmptyNode: true},
+		},
+		{
+			name:  "scale-down removing node with pods",
+			event: EventInfo{Reason: EventReasonScaleDown, Message: "Scale-down: removing node shoot--garden--aws-worker-a-z1-5b8c9-xk2lp, utilization: {0.12 0.08 0 cpu 0.12}, pods to reschedule: default/web-5d4f7"},
+			want:  ScaleDownEvent{NodeName: "shoot--garden--aws-worker-a-z1-5b8c9-xk2lp"},
+		},
+		{
+			name:  "scale-down node removed with drain",
+			event: EventInfo{Reason: EventReasonScaleDown, Message: "Scale-down: node shoot--garden--aws-worker-a-z1-5b8c9-xk2lp removed with drain"},
+			want:  ScaleDownEvent{NodeName: "shoot--garden--aws-worker-a-z1-5b8c9-xk2lp"},
+		},
+		{
+			name:  "scale-down pod eviction on node",
+			event: EventInfo{Reason: EventReasonScaleDown, Message: "deleting pod for node scale down", InvolvedObjectKind: "Pod", InvolvedObjectName: "web-5d4f7"},
+			want:  ScaleDownEvent{},
+		},
+		{
+			name:  "scale-down event on node",
+			event: EventInfo{Reason: EventReasonScaleDown, Message: "node removed by cluster autoscaler", InvolvedObjectKind: "Node", InvolvedObjectName: "node-1"},
+			want:  ScaleDownEvent{NodeName: "node-1"},
+		},
+		{
+			name:  "failed scheduling with preemption",
+			event: EventInfo{Reason: EventReasonFailedScheduling, Message: "0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had untolerated taint {node.kubernetes.io/unreachable: }. preemption: 0/3 nodes are available: 1 No preemption victims found for incoming pod, 2 Preemption is not helpful for scheduling."},
+			want: FailedSchedulingEvent{
+				AvailableNodes: 0,
+				TotalNodes:     3,
+				Failures: []PredicateFailure{
+					{Count: 1, Reason: "Insufficient cpu"},
+					{Count: 2, Reason: "node(s) had untolerated taint {node.kubernetes.io/unreachable: }"},
+				},
+				PreemptionFailures: []PredicateFailure{
+					{Count: 1, Reason: "No preemption victims found for incoming pod"},
+					{Count: 2, Reason: "Preemption is not helpful for scheduling"},
+				},
+			},
+		},
+		{
+			name:  "scale-up timed out",
+			event: EventInfo{Reason: EventReasonScaleUpTimedOut, Message: "Nodes added to group shoot--garden--aws-worker-a-z1 failed to register within 20m0s"},
+			want:  ScaleUpTimedOutEvent{NodeGroupName: "shoot--garden--aws-worker-a-z1", Timeout: 20 * time.Minute},
+		},
+		{
+			name:    "scale-up timed out without node group",
+			event:   EventInfo{Reason: EventReasonScaleUpTimedOut, Message: "Nodes failed to register"},
+			want:    ScaleUpTimedOutEvent{},
+			wantErr: ErrUnexpectedEventMessage,
+		},
+		{
+			name:    "unsupported reason",
+			event:   EventInfo{Reason: "Scheduled", Message: "Successfully assigned default/web to node-1"},
+			wantErr: ErrUnsupportedEventReason,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseEvent(tc.event)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.want == nil {
+				return
+			}
+			want := withEventInfo(tc.want, tc.event)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %+v, got %+v", want, got)
+			}
+		})
+	}
+}
+
+// withEventInfo sets the EventInfo of the given parsed event, which all parsers copy from their input.
+func withEventInfo(parsed ParsedEvent, e EventInfo) ParsedEvent {
+	switch p := parsed.(type) {
+	case TriggeredScaleUpEvent:
+		p.EventInfo = e
+		return p
+	case NotTriggerScaleUpEvent:
+		p.EventInfo = e
+		return p
+	case ScaleDownEvent:
+		p.EventInfo = e
+		return p
+	case FailedSchedulingEvent:
+		p.EventInfo = e
+		return p
+	case ScaleUpTimedOutEvent:
+		p.EventInfo = e
+		return p
+	}
+	return parsed
+}
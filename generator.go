@@ -0,0 +1,542 @@
+package gsc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// LabelGeneratedWorkload is the label carrying the workload name on pods created by GenerateScenario. It is used
+// as the selector of generated anti-affinity and topology spread constraints.
+const LabelGeneratedWorkload = "app"
+
+// MachineTypeSpec describes the capacity of a machine type used by generated worker pools.
+type MachineTypeSpec struct {
+	Name   string
+	CPU    resource.Quantity
+	Memory resource.Quantity
+	// MaxPods is the pod capacity of the machine type. 110 is used if zero.
+	MaxPods int64
+}
+
+// PoolSpec describes a generated worker pool. Minimum and Maximum are distributed over the Zones like Gardener does.
+type PoolSpec struct {
+	Name        string
+	MachineType string
+	Zones       []string
+	Minimum     int
+	Maximum     int
+	Labels      map[string]string
+	Taints      []corev1.Taint
+}
+
+// PriorityClassSpec describes a generated priority class.
+type PriorityClassSpec struct {
+	Name          string
+	Value         int32
+	GlobalDefault bool
+}
+
+// QuantityRange is a closed range from which quantities are drawn uniformly.
+type QuantityRange struct {
+	Min resource.Quantity
+	Max resource.Quantity
+}
+
+// WorkloadSpec describes a deployment-like workload whose Replicas are scheduled onto the initial nodes.
+type WorkloadSpec struct {
+	Name              string
+	Namespace         string
+	Replicas          int
+	CPU               QuantityRange
+	Memory            QuantityRange
+	PriorityClassName string
+	// AntiAffinity adds a required pod anti-affinity on the hostname so that no two pods share a node.
+	AntiAffinity bool
+	// TopologySpread adds a zone topology spread constraint with a max skew of one.
+	TopologySpread bool
+	Tolerations    []corev1.Toleration
+}
+
+// BurstSpec describes a burst of Pods pending pods of a workload created at Offset after the scenario start.
+type BurstSpec struct {
+	Workload string
+	Offset   time.Duration
+	Pods     int
+}
+
+// ScenarioSpec specifies a scenario generated by GenerateScenario.
+type ScenarioSpec struct {
+	// Seed makes the generated scenario reproducible.
+	Seed         int64
+	TechnicalID  string
+	Region       string
+	StartTime    time.Time
+	Interval     time.Duration
+	NumSnapshots int
+	MachineTypes []MachineTypeSpec
+	Pools        []PoolSpec
+	// PriorityClasses referenced by workloads.
+	PriorityClasses []PriorityClassSpec
+	Workloads       []WorkloadSpec
+	Bursts          []BurstSpec
+}
+
+// GenerateScenario generates NumSnapshots snapshots taken every Interval from StartTime. Every pool starts with
+// its node groups at their MinSize, the replicas of all workloads are placed onto these nodes honouring
+// resources, taints, anti-affinity and topology spread, and replicas that do not fit are left unscheduled. The
+// pods of every burst are added as unscheduled pods to all snapshots taken at or after its offset. The same spec
+// always yields the same snapshots and every snapshot passes ClusterSnapshot.Validate.
+func GenerateScenario(spec ScenarioSpec) ([]ClusterSnapshot, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	g := &scenarioGenerator{spec: spec, rnd: rand.New(rand.NewSource(spec.Seed))}
+	config, workerPools := g.generateConfig()
+	nodes := g.generateNodes(config)
+	priorityClasses := g.generatePriorityClasses()
+	pods := g.generateWorkloadPods(nodes)
+	for _, burst := range spec.Bursts {
+		workload, _ := g.getWorkload(burst.Workload)
+		for range burst.Pods {
+			pod := g.newPod(workload, spec.StartTime.Add(burst.Offset))
+			setUnschedulable(pod)
+			pods = append(pods, pod)
+		}
+	}
+
+	snapshots := make([]ClusterSnapshot, 0, spec.NumSnapshots)
+	for i := range spec.NumSnapshots {
+		snapshotTime := spec.StartTime.Add(time.Duration(i) * spec.Interval).UTC()
+		snapshot := ClusterSnapshot{
+			ID:               fmt.Sprintf("%s-%d-%d", spec.TechnicalID, spec.Seed, i),
+			Number:           i,
+			SnapshotTime:     snapshotTime,
+			AutoscalerConfig: config,
+			WorkerPools:      workerPools,
+		}
+		for _, pc := range priorityClasses {
+			snapshot.PriorityClasses = append(snapshot.PriorityClasses, AsPriorityClassInfo(pc, snapshotTime))
+		}
+		for _, n := range nodes {
+			snapshot.Nodes = append(snapshot.Nodes, AsNodeInfo(n, snapshotTime))
+		}
+		for _, p := range pods {
+			if p.CreationTimestamp.After(snapshotTime) {
+				continue
+			}
+			snapshot.Pods = append(snapshot.Pods, AsPodInfo(p, snapshotTime))
+		}
+		snapshot.AutoscalerConfig.ExistingNodes = slices.Clone(snapshot.Nodes)
+		snapshot.AutoscalerConfig.Hash = snapshot.AutoscalerConfig.GetHash()
+		snapshot.Hash = snapshot.GetHash()
+		if err := snapshot.Validate(); err != nil {
+			return nil, fmt.Errorf("cannot generate valid snapshot %d: %w", i, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+func (s ScenarioSpec) validate() error {
+	var errs []error
+	if s.TechnicalID == "" {
+		errs = append(errs, errors.New("technical ID must be set"))
+	}
+	if s.NumSnapshots < 1 {
+		errs = append(errs, fmt.Errorf("number of snapshots %d must be at least 1", s.NumSnapshots))
+	}
+	machineTypes := make(map[string]bool, len(s.MachineTypes))
+	for _, mt := range s.MachineTypes {
+		machineTypes[mt.Name] = true
+	}
+	for _, p := range s.Pools {
+		if !machineTypes[p.MachineType] {
+			errs = append(errs, fmt.Errorf("pool %q references unknown machine type %q", p.Name, p.MachineType))
+		}
+		if len(p.Zones) == 0 || p.Minimum < 0 || p.Minimum > p.Maximum {
+			errs = append(errs, fmt.Errorf("pool %q must have zones and 0 <= Minimum(%d) <= Maximum(%d)", p.Name, p.Minimum, p.Maximum))
+		}
+	}
+	priorityClasses := make(map[string]bool, len(s.PriorityClasses))
+	for _, pc := range s.PriorityClasses {
+		priorityClasses[pc.Name] = true
+	}
+	workloads := make(map[string]bool, len(s.Workloads))
+	for _, w := range s.Workloads {
+		workloads[w.Name] = true
+		if w.PriorityClassName != "" && !priorityClasses[w.PriorityClassName] {
+			errs = append(errs, fmt.Errorf("workload %q references unknown priority class %q", w.Name, w.PriorityClassName))
+		}
+		if w.CPU.Min.Cmp(w.CPU.Max) > 0 || w.Memory.Min.Cmp(w.Memory.Max) > 0 {
+			errs = append(errs, fmt.Errorf("workload %q has a request range with Min > Max", w.Name))
+		}
+	}
+	for _, b := range s.Bursts {
+		if !workloads[b.Workload] {
+			errs = append(errs, fmt.Errorf("burst references unknown workload %q", b.Workload))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot generate scenario from invalid spec: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+type scenarioGenerator struct {
+	spec ScenarioSpec
+	rnd  *rand.Rand
+}
+
+func (g *scenarioGenerator) generateConfig() (config AutoscalerConfig, workerPools []WorkerPoolInfo) {
+	startTime := g.spec.StartTime.UTC()
+	config = AutoscalerConfig{
+		NodeTemplates: make(map[string]NodeTemplate),
+		NodeGroups:    make(map[string]NodeGroupInfo),
+		CASettings: CASettingsInfo{
+			SnapshotTimestamp:             startTime,
+			Expander:                      DefaultExpander,
+			NodeGroupsMinMax:              make(map[string]MinMax),
+			MaxNodeProvisionTime:          DefaultMaxNodeProvisionTime,
+			ScanInterval:                  DefaultScanInterval,
+			MaxGracefulTerminationSeconds: DefaultMaxGracefulTerminationSeconds,
+			NewPodScaleUpDelay:            DefaultNewPodScaleUpDelay,
+			MaxEmptyBulkDelete:            DefaultMaxEmptyBulkDelete,
+			IgnoreDaemonSetUtilization:    DefaultIgnoreDaemonSetUtilization,
+		},
+	}
+	for _, p := range g.spec.Pools {
+		pool := WorkerPoolInfo{
+			SnapshotMeta: SnapshotMeta{
+				CreationTimestamp: startTime,
+				SnapshotTimestamp: startTime,
+				Name:              p.Name,
+				Namespace:         g.spec.TechnicalID,
+			},
+			MachineType:    p.MachineType,
+			Architecture:   DefaultArchitecture,
+			Minimum:        p.Minimum,
+			Maximum:        p.Maximum,
+			MaxSurge:       DefaultMaxSurge,
+			MaxUnavailable: DefaultMaxUnavailable,
+			Zones:          p.Zones,
+			Labels:         p.Labels,
+			Taints:         p.Taints,
+		}
+		pool.Hash = pool.GetHash()
+		workerPools = append(workerPools, pool)
+		machineType := g.getMachineType(p.MachineType)
+		for _, ng := range pool.ToNodeGroups() {
+			config.NodeGroups[ng.Name] = ng
+			config.NodeTemplates[ng.Name] = g.newNodeTemplate(ng, pool, machineType)
+			config.CASettings.NodeGroupsMinMax[ng.Name] = MinMax{Min: ng.MinSize, Max: ng.MaxSize}
+		}
+	}
+	config.CASettings.Hash = config.CASettings.GetHash()
+	return
+}
+
+func (g *scenarioGenerator) newNodeTemplate(ng NodeGroupInfo, pool WorkerPoolInfo, machineType MachineTypeSpec) NodeTemplate {
+	maxPods := machineType.MaxPods
+	if maxPods == 0 {
+		maxPods = 110
+	}
+	capacity := corev1.ResourceList{
+		corev1.ResourceCPU:    machineType.CPU,
+		corev1.ResourceMemory: machineType.Memory,
+		corev1.ResourcePods:   *resource.NewQuantity(maxPods, resource.DecimalSI),
+	}
+	labels := map[string]string{
+		PoolLabel:                      pool.Name,
+		corev1.LabelTopologyZone:       ng.Zone,
+		corev1.LabelTopologyRegion:     g.spec.Region,
+		corev1.LabelInstanceTypeStable: machineType.Name,
+		corev1.LabelArchStable:         pool.Architecture,
+	}
+	for k, v := range pool.Labels {
+		labels[k] = v
+	}
+	t := NodeTemplate{
+		Name:         ng.Name,
+		InstanceType: machineType.Name,
+		Region:       g.spec.Region,
+		Zone:         ng.Zone,
+		Capacity:     capacity,
+		Allocatable:  capacity.DeepCopy(),
+		Labels:       labels,
+		Taints:       pool.Taints,
+	}
+	t.Hash = t.GetHash()
+	return t
+}
+
+func (g *scenarioGenerator) generateNodes(config AutoscalerConfig) []*corev1.Node {
+	var nodes []*corev1.Node
+	for _, p := range g.spec.Pools {
+		for zoneIndex := range p.Zones {
+			ngName := GetNodeGroupName(g.spec.TechnicalID, p.Name, zoneIndex)
+			ng, template := config.NodeGroups[ngName], config.NodeTemplates[ngName]
+			for range ng.TargetSize {
+				name := fmt.Sprintf("%s-%s", ngName, g.randomSuffix(5))
+				labels := make(map[string]string, len(template.Labels)+1)
+				for k, v := range template.Labels {
+					labels[k] = v
+				}
+				labels[corev1.LabelHostname] = name
+				nodes = append(nodes, &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              name,
+						UID:               g.randomUID(),
+						Labels:            labels,
+						CreationTimestamp: metav1.NewTime(g.spec.StartTime.UTC()),
+					},
+					Spec: corev1.NodeSpec{
+						ProviderID: fmt.Sprintf("generated:///%s/%s/%s", g.spec.Region, ng.Zone, name),
+						Taints:     template.Taints,
+					},
+					Status: corev1.NodeStatus{
+						Capacity:    template.Capacity.DeepCopy(),
+						Allocatable: template.Allocatable.DeepCopy(),
+					},
+				})
+			}
+		}
+	}
+	return nodes
+}
+
+func (g *scenarioGenerator) generatePriorityClasses() []*schedulingv1.PriorityClass {
+	preemptionPolicy := corev1.PreemptLowerPriority
+	return lo.Map(g.spec.PriorityClasses, func(pc PriorityClassSpec, _ int) *schedulingv1.PriorityClass {
+		return &schedulingv1.PriorityClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              pc.Name,
+				UID:               g.randomUID(),
+				CreationTimestamp: metav1.NewTime(g.spec.StartTime.UTC()),
+			},
+			Value:            pc.Value,
+			GlobalDefault:    pc.GlobalDefault,
+			PreemptionPolicy: &preemptionPolicy,
+		}
+	})
+}
+
+// generateWorkloadPods creates the replicas of all workloads and places them onto the given nodes, leaving the
+// replicas that do not fit unscheduled.
+func (g *scenarioGenerator) generateWorkloadPods(nodes []*corev1.Node) []*corev1.Pod {
+	p := newPlacer(nodes)
+	var pods []*corev1.Pod
+	for _, w := range g.spec.Workloads {
+		for range w.Replicas {
+			pod := g.newPod(w, g.spec.StartTime)
+			if node := p.place(pod, w); node != nil {
+				pod.Spec.NodeName = node.Name
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}}
+			} else {
+				setUnschedulable(pod)
+			}
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
+func (g *scenarioGenerator) newPod(w WorkloadSpec, creationTime time.Time) *corev1.Pod {
+	namespace := w.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{LabelGeneratedWorkload: w.Name}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              fmt.Sprintf("%s-%s", w.Name, g.randomSuffix(10)),
+			Namespace:         namespace,
+			UID:               g.randomUID(),
+			Labels:            map[string]string{LabelGeneratedWorkload: w.Name},
+			CreationTimestamp: metav1.NewTime(creationTime.UTC()),
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  w.Name,
+				Image: "registry.k8s.io/pause:3.9",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    g.randomQuantity(w.CPU, resource.DecimalSI, 1),
+						corev1.ResourceMemory: g.randomQuantity(w.Memory, resource.BinarySI, 1024*1024),
+					},
+				},
+			}},
+			PriorityClassName: w.PriorityClassName,
+			Tolerations:       w.Tolerations,
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	if w.PriorityClassName != "" {
+		priority := g.getPriorityClass(w.PriorityClassName).Value
+		pod.Spec.Priority = &priority
+	}
+	if w.AntiAffinity {
+		pod.Spec.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+				LabelSelector: selector,
+				TopologyKey:   corev1.LabelHostname,
+			}},
+		}}
+	}
+	if w.TopologySpread {
+		pod.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelTopologyZone,
+			WhenUnsatisfiable: corev1.DoNotSchedule,
+			LabelSelector:     selector,
+		}}
+	}
+	return pod
+}
+
+// randomQuantity draws a quantity uniformly from the range, rounded down to a multiple of unit in milli (for
+// DecimalSI) or base units (for BinarySI).
+func (g *scenarioGenerator) randomQuantity(r QuantityRange, format resource.Format, unit int64) resource.Quantity {
+	if format == resource.DecimalSI {
+		low, high := r.Min.MilliValue(), r.Max.MilliValue()
+		return *resource.NewMilliQuantity(low+g.rnd.Int63n(high-low+1), format)
+	}
+	low, high := r.Min.Value(), r.Max.Value()
+	v := low + g.rnd.Int63n(high-low+1)
+	if rounded := v - v%unit; rounded >= low {
+		v = rounded
+	}
+	return *resource.NewQuantity(v, format)
+}
+
+func (g *scenarioGenerator) randomSuffix(n int) string {
+	const alphabet = "bcdfghjklmnpqrstvwxz2456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[g.rnd.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+func (g *scenarioGenerator) randomUID() types.UID {
+	return types.UID(fmt.Sprintf("%08x-%04x-%04x-%04x-%012x", g.rnd.Uint32(), g.rnd.Intn(1<<16), g.rnd.Intn(1<<16), g.rnd.Intn(1<<16), g.rnd.Int63n(1<<48)))
+}
+
+func (g *scenarioGenerator) getMachineType(name string) MachineTypeSpec {
+	i := slices.IndexFunc(g.spec.MachineTypes, func(mt MachineTypeSpec) bool { return mt.Name == name })
+	return g.spec.MachineTypes[i]
+}
+
+func (g *scenarioGenerator) getPriorityClass(name string) PriorityClassSpec {
+	i := slices.IndexFunc(g.spec.PriorityClasses, func(pc PriorityClassSpec) bool { return pc.Name == name })
+	return g.spec.PriorityClasses[i]
+}
+
+func (g *scenarioGenerator) getWorkload(name string) (WorkloadSpec, bool) {
+	i := slices.IndexFunc(g.spec.Workloads, func(w WorkloadSpec) bool { return w.Name == name })
+	if i < 0 {
+		return WorkloadSpec{}, false
+	}
+	return g.spec.Workloads[i], true
+}
+
+func setUnschedulable(pod *corev1.Pod) {
+	pod.Status.Phase = corev1.PodPending
+	pod.Status.Conditions = []corev1.PodCondition{{
+		Type:   corev1.PodScheduled,
+		Status: corev1.ConditionFalse,
+		Reason: corev1.PodReasonUnschedulable,
+	}}
+}
+
+// placer is a minimal first-fit scheduler used to place generated workload replicas onto the initial nodes.
+type placer struct {
+	nodes []*corev1.Node
+	// free is the allocatable of each node minus the requests of the pods placed on it.
+	free map[string]corev1.ResourceList
+	// workloadsOnNode counts the pods of each workload per node.
+	workloadsOnNode map[string]map[string]int
+}
+
+func newPlacer(nodes []*corev1.Node) *placer {
+	p := &placer{
+		nodes:           nodes,
+		free:            make(map[string]corev1.ResourceList, len(nodes)),
+		workloadsOnNode: make(map[string]map[string]int, len(nodes)),
+	}
+	for _, n := range nodes {
+		p.free[n.Name] = n.Status.Allocatable.DeepCopy()
+		p.workloadsOnNode[n.Name] = make(map[string]int)
+	}
+	return p
+}
+
+// place returns the node the pod was placed on or nil if it does not fit on any node.
+func (p *placer) place(pod *corev1.Pod, w WorkloadSpec) *corev1.Node {
+	requests := CumulatePodRequests(pod)
+	requests[corev1.ResourcePods] = *resource.NewQuantity(1, resource.DecimalSI)
+	candidates := slices.DeleteFunc(slices.Clone(p.nodes), func(n *corev1.Node) bool {
+		if w.AntiAffinity && p.workloadsOnNode[n.Name][w.Name] > 0 {
+			return true
+		}
+		return !tolerates(pod.Spec.Tolerations, n.Spec.Taints) || !fits(requests, p.free[n.Name])
+	})
+	if len(candidates) == 0 {
+		return nil
+	}
+	node := candidates[0]
+	if w.TopologySpread {
+		podsInZone := make(map[string]int)
+		for _, n := range p.nodes {
+			podsInZone[n.Labels[corev1.LabelTopologyZone]] += p.workloadsOnNode[n.Name][w.Name]
+		}
+		minZonePods := slices.Min(lo.Map(p.nodes, func(n *corev1.Node, _ int) int {
+			return podsInZone[n.Labels[corev1.LabelTopologyZone]]
+		}))
+		i := slices.IndexFunc(candidates, func(n *corev1.Node) bool {
+			return podsInZone[n.Labels[corev1.LabelTopologyZone]]+1-minZonePods <= 1
+		})
+		if i < 0 {
+			return nil
+		}
+		node = candidates[i]
+	}
+	free := p.free[node.Name]
+	for name, q := range requests {
+		remaining := free[name]
+		remaining.Sub(q)
+		free[name] = remaining
+	}
+	p.workloadsOnNode[node.Name][w.Name]++
+	return node
+}
+
+func fits(requests, free corev1.ResourceList) bool {
+	for name, q := range requests {
+		if available, ok := free[name]; ok && q.Cmp(available) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func tolerates(tolerations []corev1.Toleration, taints []corev1.Taint) bool {
+	for _, taint := range taints {
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !slices.ContainsFunc(tolerations, func(t corev1.Toleration) bool { return t.ToleratesTaint(&taint) }) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package gsc
+
+import (
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestScenarioSpec(seed int64) ScenarioSpec {
+	return ScenarioSpec{
+		Seed:         seed,
+		TechnicalID:  "shoot--test--gen",
+		Region:       "eu-west-1",
+		StartTime:    time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC),
+		Interval:     time.Minute,
+		NumSnapshots: 3,
+		MachineTypes: []MachineTypeSpec{{Name: "m5.large", CPU: resource.MustParse("2"), Memory: resource.MustParse("8Gi")}},
+		Pools: []PoolSpec{{
+			Name:        "a",
+			MachineType: "m5.large",
+			Zones:       []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"},
+			Minimum:     3,
+			Maximum:     9,
+		}},
+		PriorityClasses: []PriorityClassSpec{{Name: "high", Value: 1000}},
+		Workloads: []WorkloadSpec{
+			{
+				Name:              "web",
+				Replicas:          5,
+				CPU:               QuantityRange{Min: resource.MustParse("100m"), Max: resource.MustParse("200m")},
+				Memory:            QuantityRange{Min: resource.MustParse("128Mi"), Max: resource.MustParse("256Mi")},
+				PriorityClassName: "high",
+				AntiAffinity:      true,
+			},
+			{
+				Name:           "worker",
+				Replicas:       6,
+				CPU:            QuantityRange{Min: resource.MustParse("100m"), Max: resource.MustParse("300m")},
+				Memory:         QuantityRange{Min: resource.MustParse("64Mi"), Max: resource.MustParse("128Mi")},
+				TopologySpread: true,
+			},
+		},
+		Bursts: []BurstSpec{{Workload: "worker", Offset: 90 * time.Second, Pods: 2}},
+	}
+}
+
+func snapshotHashes(snapshots []ClusterSnapshot) []string {
+	return lo.Map(snapshots, func(s ClusterSnapshot, _ int) string { return s.Hash })
+}
+
+func TestGenerateScenarioIsReproducible(t *testing.T) {
+	first, err := GenerateScenario(newTestScenarioSpec(42))
+	if err != nil {
+		t.Fatalf("cannot generate scenario: %v", err)
+	}
+	second, err := GenerateScenario(newTestScenarioSpec(42))
+	if err != nil {
+		t.Fatalf("cannot generate scenario: %v", err)
+	}
+	other, err := GenerateScenario(newTestScenarioSpec(7))
+	if err != nil {
+		t.Fatalf("cannot generate scenario: %v", err)
+	}
+	if got, want := snapshotHashes(second), snapshotHashes(first); !slices.Equal(got, want) {
+		t.Errorf("expected same seed to yield hashes %v, got %v", want, got)
+	}
+	for i, h := range snapshotHashes(other) {
+		if h == first[i].Hash {
+			t.Errorf("expected different seeds to yield different hashes for snapshot %d", i)
+		}
+	}
+}
+
+func TestGenerateScenarioPlacement(t *testing.T) {
+	snapshots, err := GenerateScenario(newTestScenarioSpec(42))
+	if err != nil {
+		t.Fatalf("cannot generate scenario: %v", err)
+	}
+	if len(snapshots) != 3 {
+		t.Fatalf("expected 3 snapshots, got %d", len(snapshots))
+	}
+	first, last := snapshots[0], snapshots[2]
+	if len(first.Nodes) != 3 {
+		t.Fatalf("expected one node per zone, got %d nodes", len(first.Nodes))
+	}
+	zonesByNode := make(map[string]string, len(first.Nodes))
+	for _, n := range first.Nodes {
+		zonesByNode[n.Name] = n.Labels[corev1.LabelTopologyZone]
+	}
+	workloadPods := func(s ClusterSnapshot, workload string) []PodInfo {
+		return lo.Filter(s.Pods, func(p PodInfo, _ int) bool { return p.Labels[LabelGeneratedWorkload] == workload })
+	}
+
+	webNodes := make(map[string]bool)
+	var webUnscheduled int
+	for _, p := range workloadPods(first, "web") {
+		if p.NodeName == "" {
+			webUnscheduled++
+			continue
+		}
+		if webNodes[p.NodeName] {
+			t.Errorf("anti-affinity violated: two web pods on node %q", p.NodeName)
+		}
+		webNodes[p.NodeName] = true
+	}
+	if len(webNodes) != 3 || webUnscheduled != 2 {
+		t.Errorf("expected 3 web pods on distinct nodes and 2 unscheduled, got %d scheduled and %d unscheduled", len(webNodes), webUnscheduled)
+	}
+
+	podsPerZone := make(map[string]int)
+	for _, p := range workloadPods(first, "worker") {
+		if p.NodeName == "" {
+			t.Errorf("expected worker pod %q to be scheduled", p.Name)
+			continue
+		}
+		podsPerZone[zonesByNode[p.NodeName]]++
+	}
+	if len(podsPerZone) != 3 {
+		t.Errorf("expected worker pods spread over 3 zones, got %v", podsPerZone)
+	}
+	for zone, count := range podsPerZone {
+		if count != 2 {
+			t.Errorf("expected 2 worker pods in zone %q with max skew 1, got %d", zone, count)
+		}
+	}
+
+	if got, want := len(workloadPods(last, "worker")), 8; got != want {
+		t.Errorf("expected %d worker pods including the burst in the last snapshot, got %d", want, got)
+	}
+	for _, s := range snapshots {
+		if err = s.Validate(); err != nil {
+			t.Errorf("expected generated snapshot %q to be valid: %v", s.ID, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package gsc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ImportedObjects are the nodes, pods and priority classes decoded from kubectl output.
+type ImportedObjects struct {
+	Nodes           []corev1.Node
+	Pods            []corev1.Pod
+	PriorityClasses []schedulingv1.PriorityClass
+}
+
+// DecodeKubeObjects decodes a stream of JSON or YAML documents as written by `kubectl get -o json|yaml`. Every
+// document may be a single object or a List, including typed lists like NodeList. Objects of kinds other than
+// Node, Pod and PriorityClass are skipped.
+func DecodeKubeObjects(r io.Reader) (objs ImportedObjects, err error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var doc map[string]any
+		if err = decoder.Decode(&doc); errors.Is(err, io.EOF) {
+			return objs, nil
+		}
+		if err != nil {
+			return objs, fmt.Errorf("cannot decode kube objects: %w", err)
+		}
+		if doc == nil {
+			continue
+		}
+		if err = objs.add(doc); err != nil {
+			return objs, err
+		}
+	}
+}
+
+// ImportKubeObjectFiles decodes the given files with DecodeKubeObjects. A directory is treated as the output
+// directory of `kubectl cluster-info dump --output-directory`, whose JSON and YAML files are decoded recursively.
+func ImportKubeObjectFiles(paths ...string) (objs ImportedObjects, err error) {
+	for _, path := range paths {
+		var info fs.FileInfo
+		if info, err = os.Stat(path); err != nil {
+			return objs, fmt.Errorf("cannot import %q: %w", path, err)
+		}
+		if !info.IsDir() {
+			if err = objs.addFile(path); err != nil {
+				return
+			}
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, walkErr error) error {
+			if walkErr != nil || d.IsDir() {
+				return walkErr
+			}
+			switch strings.ToLower(filepath.Ext(p)) {
+			case ".json", ".yaml", ".yml":
+				return objs.addFile(p)
+			}
+			return nil
+		})
+		if err != nil {
+			return objs, fmt.Errorf("cannot import cluster-info dump %q: %w", path, err)
+		}
+	}
+	return
+}
+
+// ToClusterSnapshot converts the objects into a ClusterSnapshot captured at snapshotTime using AsNodeInfo, AsPodInfo
+// and AsPriorityClassInfo. Objects present multiple times, such as in overlapping dumps, are only taken once.
+// Nodes and priority classes are sorted by name and pods by namespace and name, and all hashes are filled.
+func (o ImportedObjects) ToClusterSnapshot(snapshotTime time.Time) ClusterSnapshot {
+	snapshot := ClusterSnapshot{SnapshotTime: snapshotTime.UTC()}
+	seen := make(map[string]bool)
+	isNew := func(kind, namespace, name string) bool {
+		key := kind + "/" + namespace + "/" + name
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	}
+	for i := range o.PriorityClasses {
+		if pc := &o.PriorityClasses[i]; isNew("PriorityClass", "", pc.Name) {
+			snapshot.PriorityClasses = append(snapshot.PriorityClasses, AsPriorityClassInfo(pc, snapshotTime))
+		}
+	}
+	for i := range o.Nodes {
+		if n := &o.Nodes[i]; isNew("Node", "", n.Name) {
+			snapshot.Nodes = append(snapshot.Nodes, AsNodeInfo(n, snapshotTime))
+		}
+	}
+	for i := range o.Pods {
+		if p := &o.Pods[i]; isNew("Pod", p.Namespace, p.Name) {
+			snapshot.Pods = append(snapshot.Pods, AsPodInfo(p, snapshotTime))
+		}
+	}
+	slices.SortFunc(snapshot.PriorityClasses, func(a, b PriorityClassInfo) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(snapshot.Nodes, func(a, b NodeInfo) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(snapshot.Pods, func(a, b PodInfo) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+	snapshot.Hash = snapshot.GetHash()
+	return snapshot
+}
+
+func (o *ImportedObjects) addFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read %q: %w", path, err)
+	}
+	decoded, err := DecodeKubeObjects(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("cannot import %q: %w", path, err)
+	}
+	o.Nodes = append(o.Nodes, decoded.Nodes...)
+	o.Pods = append(o.Pods, decoded.Pods...)
+	o.PriorityClasses = append(o.PriorityClasses, decoded.PriorityClasses...)
+	return nil
+}
+
+func (o *ImportedObjects) add(obj map[string]any) error {
+	u := unstructured.Unstructured{Object: obj}
+	if u.IsList() {
+		items, _, err := unstructured.NestedSlice(obj, "items")
+		if err != nil {
+			return fmt.Errorf("cannot get items of %s: %w", u.GetKind(), err)
+		}
+		// items of typed lists written by cluster-info dump lack their kind
+		itemKind := strings.TrimSuffix(u.GetKind(), "List")
+		for _, item := range items {
+			itemObj, ok := item.(map[string]any)
+			if !ok {
+				return fmt.Errorf("cannot decode item of %s: unexpected type %T", u.GetKind(), item)
+			}
+			if _, ok = itemObj["kind"]; !ok && itemKind != "" {
+				itemObj["kind"] = itemKind
+			}
+			if err = o.add(itemObj); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var err error
+	switch u.GetKind() {
+	case "Node":
+		var node corev1.Node
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &node); err == nil {
+			o.Nodes = append(o.Nodes, node)
+		}
+	case "Pod":
+		var pod corev1.Pod
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &pod); err == nil {
+			o.Pods = append(o.Pods, pod)
+		}
+	case "PriorityClass":
+		var pc schedulingv1.PriorityClass
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &pc); err == nil {
+			o.PriorityClasses = append(o.PriorityClasses, pc)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("cannot convert %s %q: %w", u.GetKind(), u.GetName(), err)
+	}
+	return nil
+}
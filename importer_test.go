@@ -0,0 +1,131 @@
+package gsc
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var importerTestSnapshotTime = time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+
+func TestImportKubeObjectFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		paths       []string
+		wantNodes   []string
+		wantPods    []string
+		wantPCs     []string
+		wantImports int
+	}{
+		{
+			name:        "list json skipping unknown kinds",
+			paths:       []string{"list.json"},
+			wantNodes:   []string{"node-b"},
+			wantPods:    []string{"shop/cart-0"},
+			wantPCs:     []string{"shop-critical"},
+			wantImports: 3,
+		},
+		{
+			name:        "typed list and multiple documents in yaml",
+			paths:       []string{"list.yaml"},
+			wantPods:    []string{"shop/web-0"},
+			wantPCs:     []string{"system-node-critical"},
+			wantImports: 2,
+		},
+		{
+			name:        "cluster-info dump directory",
+			paths:       []string{"clusterinfo-dump"},
+			wantNodes:   []string{"node-a"},
+			wantPods:    []string{"kube-system/coredns-1", "shop/web-0"},
+			wantImports: 3,
+		},
+		{
+			name:        "overlapping lists and dump",
+			paths:       []string{"list.yaml", "clusterinfo-dump", "list.json"},
+			wantNodes:   []string{"node-a", "node-b"},
+			wantPods:    []string{"kube-system/coredns-1", "shop/cart-0", "shop/web-0"},
+			wantPCs:     []string{"shop-critical", "system-node-critical"},
+			wantImports: 8,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			paths := make([]string, 0, len(tc.paths))
+			for _, p := range tc.paths {
+				paths = append(paths, filepath.Join("testdata", p))
+			}
+			objs, err := ImportKubeObjectFiles(paths...)
+			if err != nil {
+				t.Fatalf("cannot import %v: %v", paths, err)
+			}
+			if imports := len(objs.Nodes) + len(objs.Pods) + len(objs.PriorityClasses); imports != tc.wantImports {
+				t.Errorf("expected %d imported objects, got %d", tc.wantImports, imports)
+			}
+			snapshot := objs.ToClusterSnapshot(importerTestSnapshotTime)
+			var nodes, pods, pcs []string
+			for _, n := range snapshot.Nodes {
+				nodes = append(nodes, n.Name)
+			}
+			for _, p := range snapshot.Pods {
+				pods = append(pods, p.Namespace+"/"+p.Name)
+			}
+			for _, pc := range snapshot.PriorityClasses {
+				pcs = append(pcs, pc.Name)
+			}
+			if !slices.Equal(nodes, tc.wantNodes) || !slices.Equal(pods, tc.wantPods) || !slices.Equal(pcs, tc.wantPCs) {
+				t.Errorf("expected nodes %v, pods %v and priority classes %v, got %v, %v and %v", tc.wantNodes, tc.wantPods, tc.wantPCs, nodes, pods, pcs)
+			}
+			if snapshot.Hash == "" || snapshot.Hash != snapshot.GetHash() {
+				t.Errorf("expected hash of snapshot to be set, got %q", snapshot.Hash)
+			}
+		})
+	}
+}
+
+func TestImportKubeObjectFilesConvertsObjects(t *testing.T) {
+	objs, err := ImportKubeObjectFiles(filepath.Join("testdata", "list.json"), filepath.Join("testdata", "list.yaml"))
+	if err != nil {
+		t.Fatalf("cannot import lists: %v", err)
+	}
+	snapshot := objs.ToClusterSnapshot(importerTestSnapshotTime)
+
+	node := snapshot.Nodes[0]
+	if node.ProviderID != "aws:///eu-west-1a/i-b" || len(node.Taints) != 1 || node.Taints[0].Key != "dedicated" {
+		t.Errorf("expected node-b with provider ID and taint, got %s", node)
+	}
+	if want := resource.MustParse("1920m"); !node.Allocatable.Cpu().Equal(want) {
+		t.Errorf("expected allocatable cpu %s, got %s", want.String(), node.Allocatable.Cpu())
+	}
+
+	pc := snapshot.PriorityClasses[0]
+	if pc.Value != 100000 || pc.PreemptionPolicy == nil || *pc.PreemptionPolicy != corev1.PreemptNever || pc.Description != "Critical shop workloads" {
+		t.Errorf("expected priority class shop-critical with value 100000 and preemption policy Never, got %s", pc)
+	}
+	if pc := snapshot.PriorityClasses[1]; pc.Value != 2000001000 || !strings.Contains(pc.String(), "PreemptionPolicy=,") {
+		t.Errorf("expected priority class system-node-critical without preemption policy, got %s", pc)
+	}
+
+	cart, web := snapshot.Pods[0], snapshot.Pods[1]
+	if cart.PodScheduleStatus != PodUnscheduled || cart.Spec.PriorityClassName != "shop-critical" {
+		t.Errorf("expected unscheduled pod shop/cart-0 of priority class shop-critical, got %s", cart)
+	}
+	if want := resource.MustParse("256Mi"); !cart.Requests.Memory().Equal(want) {
+		t.Errorf("expected memory request %s of pod shop/cart-0, got %s", want.String(), cart.Requests.Memory())
+	}
+	if web.PodScheduleStatus != PodScheduleCommited || web.NodeName != "node-b" || web.UID != "uid-web-0" {
+		t.Errorf("expected pod shop/web-0 bound to node-b, got %s", web)
+	}
+	if !web.SnapshotTimestamp.Equal(importerTestSnapshotTime) || !web.CreationTimestamp.Equal(time.Date(2024, 7, 1, 9, 10, 0, 0, time.UTC)) {
+		t.Errorf("expected snapshot and creation timestamps of pod shop/web-0, got %s and %s", web.SnapshotTimestamp, web.CreationTimestamp)
+	}
+}
+
+func TestImportKubeObjectFilesMissingFile(t *testing.T) {
+	if _, err := ImportKubeObjectFiles(filepath.Join("testdata", "missing.json")); err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Errorf("expected error naming the missing file, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package gsc
+
+import (
+	"fmt"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+)
+
+// KWOK conventions for nodes managed by a KWOK controller.
+// See https://kwok.sigs.k8s.io/docs/user/kwok-in-cluster/
+const (
+	KWOKNodeAnnotation     = "kwok.x-k8s.io/node"
+	KWOKNodeAnnotationFake = "fake"
+	KWOKNodeTypeLabel      = "type"
+	KWOKNodeTypeLabelValue = "kwok"
+	KWOKProviderIDPrefix   = "kwok://"
+)
+
+// KWOKExportOptions tune ExportKWOKManifests.
+type KWOKExportOptions struct {
+	// TaintNodes adds the KWOK taint to all nodes and a matching toleration to all pods so that only exported pods
+	// get scheduled onto the fake nodes.
+	TaintNodes bool
+}
+
+// ExportKWOKManifests writes the priority classes, namespaces, nodes and pods of the snapshot as multi-document
+// YAML that can be applied to a kind cluster running KWOK. Nodes carry the KWOK annotation and label, their
+// capacity, allocatable, labels and taints and a fake provider ID derived from the node group the node resolves to
+// in the snapshot AutoscalerConfig. Pods are stripped to their scheduling-relevant fields by AsPod and keep their
+// binding to nodes.
+func ExportKWOKManifests(w io.Writer, snapshot ClusterSnapshot, opts KWOKExportOptions) error {
+	kwokTaint := corev1.Taint{Key: KWOKNodeAnnotation, Value: KWOKNodeAnnotationFake, Effect: corev1.TaintEffectNoSchedule}
+	var objs []any
+	for _, pc := range snapshot.PriorityClasses {
+		objs = append(objs, AsPriorityClass(pc))
+	}
+	for _, ns := range sets.List(snapshot.GetPodNamspaces()) {
+		if ns == metav1.NamespaceDefault || ns == metav1.NamespaceSystem {
+			continue
+		}
+		objs = append(objs, &corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: ns},
+		})
+	}
+	resolver := NewNodeGroupResolver(snapshot.AutoscalerConfig.NodeGroups, nil, nil)
+	for _, n := range snapshot.Nodes {
+		node := AsNode(n)
+		if node.Labels == nil {
+			node.Labels = make(map[string]string)
+		}
+		node.Labels[KWOKNodeTypeLabel] = KWOKNodeTypeLabelValue
+		node.Annotations = map[string]string{KWOKNodeAnnotation: KWOKNodeAnnotationFake}
+		node.Spec.ProviderID = KWOKProviderIDPrefix + n.Name
+		if ng, ok := resolver.Resolve(n); ok {
+			node.Spec.ProviderID = KWOKProviderIDPrefix + ng.Name + "/" + n.Name
+		}
+		if opts.TaintNodes {
+			node.Spec.Taints = append(node.Spec.Taints, kwokTaint)
+		}
+		objs = append(objs, node)
+	}
+	for _, p := range snapshot.Pods {
+		pod := AsPod(p)
+		if opts.TaintNodes {
+			pod.Spec.Tolerations = append(pod.Spec.Tolerations, corev1.Toleration{
+				Key:      kwokTaint.Key,
+				Operator: corev1.TolerationOpExists,
+				Effect:   kwokTaint.Effect,
+			})
+		}
+		objs = append(objs, pod)
+	}
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("cannot marshal KWOK manifest of snapshot %q: %w", snapshot.ID, err)
+		}
+		if _, err = fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return fmt.Errorf("cannot write KWOK manifest of snapshot %q: %w", snapshot.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package gsc
+
+import (
+	"bytes"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"sigs.k8s.io/yaml"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newKWOKTestSnapshot() ClusterSnapshot {
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("4Gi"),
+	}
+	return ClusterSnapshot{
+		ID: "s1",
+		AutoscalerConfig: AutoscalerConfig{
+			NodeGroups: map[string]NodeGroupInfo{
+				"shoot--dev--aws-a-z1": {Name: "shoot--dev--aws-a-z1", PoolName: "a", Zone: "eu-west-1a"},
+			},
+		},
+		PriorityClasses: []PriorityClassInfo{{
+			PriorityClass: schedulingv1.PriorityClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "high", UID: "uid-high", ResourceVersion: "42"},
+				Value:      1000,
+			},
+		}},
+		Nodes: []NodeInfo{
+			{
+				SnapshotMeta: SnapshotMeta{Name: "node-1"},
+				ProviderID:   "aws:///eu-west-1a/i-1",
+				Labels:       map[string]string{PoolLabel: "a", corev1.LabelTopologyZone: "eu-west-1a"},
+				Taints:       []corev1.Taint{{Key: "dedicated", Value: "shop", Effect: corev1.TaintEffectNoSchedule}},
+				Allocatable:  resources,
+				Capacity:     resources,
+			},
+			{
+				SnapshotMeta: SnapshotMeta{Name: "node-2"},
+				ProviderID:   "aws:///eu-west-1b/i-2",
+				Allocatable:  resources,
+				Capacity:     resources,
+			},
+		},
+		Pods: []PodInfo{
+			{
+				SnapshotMeta: SnapshotMeta{Name: "web", Namespace: "shop"},
+				UID:          "uid-web",
+				NodeName:     "node-1",
+				Labels:       map[string]string{"app": "web"},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:         "web",
+						Image:        "nginx:1.27",
+						Command:      []string{"nginx"},
+						Env:          []corev1.EnvVar{{Name: "PORT", Value: "8080"}},
+						VolumeMounts: []corev1.VolumeMount{{Name: "config", MountPath: "/etc/nginx"}},
+						Resources:    corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+					}},
+					Volumes:            []corev1.Volume{{Name: "config"}},
+					ServiceAccountName: "web",
+					PriorityClassName:  "high",
+				},
+				PodScheduleStatus: PodScheduleCommited,
+			},
+			{
+				SnapshotMeta: SnapshotMeta{Name: "dns", Namespace: metav1.NamespaceSystem},
+				UID:          "uid-dns",
+				Spec:         corev1.PodSpec{Containers: []corev1.Container{{Name: "dns", Image: "coredns:1.11"}}},
+			},
+		},
+	}
+}
+
+// decodeKWOKManifests splits the multi-document YAML written by ExportKWOKManifests and decodes every document into
+// the object of its kind.
+func decodeKWOKManifests(t *testing.T, data string) (pcs []schedulingv1.PriorityClass, namespaces []corev1.Namespace, nodes []corev1.Node, pods []corev1.Pod) {
+	t.Helper()
+	if !strings.HasPrefix(data, "---\n") {
+		t.Fatalf("expected manifests to start with a document separator, got %q", data)
+	}
+	for _, doc := range strings.Split(data, "---\n")[1:] {
+		var typeMeta metav1.TypeMeta
+		if err := yaml.Unmarshal([]byte(doc), &typeMeta); err != nil {
+			t.Fatalf("cannot decode kind of manifest %q: %v", doc, err)
+		}
+		var err error
+		switch typeMeta.Kind {
+		case "PriorityClass":
+			var pc schedulingv1.PriorityClass
+			err = yaml.UnmarshalStrict([]byte(doc), &pc)
+			pcs = append(pcs, pc)
+		case "Namespace":
+			var ns corev1.Namespace
+			err = yaml.UnmarshalStrict([]byte(doc), &ns)
+			namespaces = append(namespaces, ns)
+		case "Node":
+			var node corev1.Node
+			err = yaml.UnmarshalStrict([]byte(doc), &node)
+			nodes = append(nodes, node)
+		case "Pod":
+			var pod corev1.Pod
+			err = yaml.UnmarshalStrict([]byte(doc), &pod)
+			pods = append(pods, pod)
+		default:
+			t.Fatalf("unexpected kind %q of manifest %q", typeMeta.Kind, doc)
+		}
+		if err != nil {
+			t.Fatalf("cannot decode %s manifest %q: %v", typeMeta.Kind, doc, err)
+		}
+	}
+	return
+}
+
+func TestExportKWOKManifests(t *testing.T) {
+	kwokTaint := corev1.Taint{Key: KWOKNodeAnnotation, Value: KWOKNodeAnnotationFake, Effect: corev1.TaintEffectNoSchedule}
+	kwokToleration := corev1.Toleration{Key: KWOKNodeAnnotation, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+	snapshotTaint := corev1.Taint{Key: "dedicated", Value: "shop", Effect: corev1.TaintEffectNoSchedule}
+	tests := []struct {
+		name            string
+		opts            KWOKExportOptions
+		wantTaints      map[string][]corev1.Taint
+		wantTolerations []corev1.Toleration
+	}{
+		{
+			name:       "without KWOK taint",
+			wantTaints: map[string][]corev1.Taint{"node-1": {snapshotTaint}, "node-2": nil},
+		},
+		{
+			name:            "with KWOK taint",
+			opts:            KWOKExportOptions{TaintNodes: true},
+			wantTaints:      map[string][]corev1.Taint{"node-1": {snapshotTaint, kwokTaint}, "node-2": {kwokTaint}},
+			wantTolerations: []corev1.Toleration{kwokToleration},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			snapshot := newKWOKTestSnapshot()
+			var buf bytes.Buffer
+			if err := ExportKWOKManifests(&buf, snapshot, tc.opts); err != nil {
+				t.Fatalf("cannot export KWOK manifests: %v", err)
+			}
+			pcs, namespaces, nodes, pods := decodeKWOKManifests(t, buf.String())
+
+			if len(pcs) != 1 || pcs[0].Name != "high" || pcs[0].Value != 1000 || pcs[0].UID != "" || pcs[0].ResourceVersion != "" {
+				t.Errorf("expected priority class high without server-populated metadata, got %v", pcs)
+			}
+			if len(namespaces) != 1 || namespaces[0].Name != "shop" {
+				t.Errorf("expected only namespace shop, got %v", namespaces)
+			}
+
+			wantProviderIDs := map[string]string{
+				"node-1": KWOKProviderIDPrefix + "shoot--dev--aws-a-z1/node-1",
+				"node-2": KWOKProviderIDPrefix + "node-2",
+			}
+			if len(nodes) != len(wantProviderIDs) {
+				t.Fatalf("expected %d nodes, got %d", len(wantProviderIDs), len(nodes))
+			}
+			for i, node := range nodes {
+				want := snapshot.Nodes[i]
+				if node.Name != want.Name || node.Spec.ProviderID != wantProviderIDs[node.Name] {
+					t.Errorf("expected node %q with provider ID %q, got %q with %q", want.Name, wantProviderIDs[want.Name], node.Name, node.Spec.ProviderID)
+				}
+				if node.Annotations[KWOKNodeAnnotation] != KWOKNodeAnnotationFake || node.Labels[KWOKNodeTypeLabel] != KWOKNodeTypeLabelValue {
+					t.Errorf("expected KWOK annotation and label on node %q, got %v and %v", node.Name, node.Annotations, node.Labels)
+				}
+				for k, v := range want.Labels {
+					if node.Labels[k] != v {
+						t.Errorf("expected label %s=%s on node %q, got %v", k, v, node.Name, node.Labels)
+					}
+				}
+				if !slices.Equal(node.Spec.Taints, tc.wantTaints[node.Name]) {
+					t.Errorf("expected taints %v on node %q, got %v", tc.wantTaints[node.Name], node.Name, node.Spec.Taints)
+				}
+				if !node.Status.Allocatable.Cpu().Equal(*want.Allocatable.Cpu()) || !node.Status.Capacity.Memory().Equal(*want.Capacity.Memory()) {
+					t.Errorf("expected allocatable %v and capacity %v on node %q, got %v and %v", want.Allocatable, want.Capacity, node.Name, node.Status.Allocatable, node.Status.Capacity)
+				}
+			}
+
+			if len(pods) != 2 {
+				t.Fatalf("expected 2 pods, got %d", len(pods))
+			}
+			web := pods[0]
+			if web.Name != "web" || web.Namespace != "shop" || web.UID != "" || web.Spec.NodeName != "node-1" || web.Spec.PriorityClassName != "high" {
+				t.Errorf("expected pod shop/web bound to node-1 with priority class high and no UID, got %+v", web.ObjectMeta)
+			}
+			wantContainers := []corev1.Container{{
+				Name:      "web",
+				Image:     "nginx:1.27",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+			}}
+			if len(web.Spec.Containers) != 1 || web.Spec.Containers[0].Name != "web" || web.Spec.Containers[0].Image != "nginx:1.27" ||
+				!web.Spec.Containers[0].Resources.Requests.Cpu().Equal(*wantContainers[0].Resources.Requests.Cpu()) ||
+				web.Spec.Containers[0].Command != nil || web.Spec.Containers[0].Env != nil || web.Spec.Containers[0].VolumeMounts != nil {
+				t.Errorf("expected containers stripped to %v, got %v", wantContainers, web.Spec.Containers)
+			}
+			if web.Spec.Volumes != nil || web.Spec.ServiceAccountName != "" || web.Spec.AutomountServiceAccountToken == nil || *web.Spec.AutomountServiceAccountToken {
+				t.Errorf("expected volumes and service account to be stripped, got %v, %q and %v", web.Spec.Volumes, web.Spec.ServiceAccountName, web.Spec.AutomountServiceAccountToken)
+			}
+			for _, pod := range pods {
+				if !reflect.DeepEqual(pod.Spec.Tolerations, tc.wantTolerations) {
+					t.Errorf("expected tolerations %v on pod %q, got %v", tc.wantTolerations, pod.Name, pod.Spec.Tolerations)
+				}
+			}
+			if dns := pods[1]; dns.Namespace != metav1.NamespaceSystem || dns.Spec.NodeName != "" {
+				t.Errorf("expected unbound pod in namespace %s, got %q bound to %q", metav1.NamespaceSystem, dns.Namespace, dns.Spec.NodeName)
+			}
+		})
+	}
+}
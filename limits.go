@@ -0,0 +1,158 @@
+package gsc
+
+import (
+	"fmt"
+	"golang.org/x/exp/maps"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"slices"
+	"strings"
+)
+
+// ScaleLimitReason describes why a requested node group delta was clamped.
+type ScaleLimitReason string
+
+const (
+	ReasonMaxNodesTotalReached  ScaleLimitReason = "max nodes total reached"
+	ReasonMaxCoresTotalReached  ScaleLimitReason = "max cores total reached"
+	ReasonMaxMemoryTotalReached ScaleLimitReason = "max memory total reached"
+	ReasonGroupAtMaxSize        ScaleLimitReason = "group at max size"
+	ReasonBelowMinSize          ScaleLimitReason = "below min size"
+	ReasonUnknownNodeGroup      ScaleLimitReason = "unknown node group"
+)
+
+// ScaleLimit records a clamp applied to the requested delta of a single node group.
+type ScaleLimit struct {
+	NodeGroupName string
+	Requested     int
+	Allowed       int
+	Reason        ScaleLimitReason
+}
+
+// ScalePlan is the result of checking a proposed map of node group deltas against the
+// size limits of an AutoscalerConfig.
+type ScalePlan struct {
+	// Deltas holds the allowed delta per node group name. Groups whose delta was clamped to zero are omitted.
+	Deltas map[string]int
+	Limits []ScaleLimit
+}
+
+func (l ScaleLimit) String() string {
+	return fmt.Sprintf("ScaleLimit(NodeGroupName=%s, Requested=%d, Allowed=%d, Reason=%s)", l.NodeGroupName, l.Requested, l.Allowed, l.Reason)
+}
+
+// IsClamped returns true if any requested delta was reduced.
+func (p ScalePlan) IsClamped() bool {
+	return len(p.Limits) > 0
+}
+
+// GetMinMax returns the min and max size of the node group with the given name, giving precedence
+// to the CASettingsInfo.NodeGroupsMinMax over the sizes recorded in the NodeGroupInfo.
+func (c AutoscalerConfig) GetMinMax(nodeGroupName string) (minMax MinMax, ok bool) {
+	ng, ok := c.NodeGroups[nodeGroupName]
+	if !ok {
+		return
+	}
+	minMax = MinMax{Min: ng.MinSize, Max: ng.MaxSize}
+	if mm, found := c.CASettings.NodeGroupsMinMax[nodeGroupName]; found {
+		minMax = mm
+	}
+	return
+}
+
+// ClampScalePlan checks the proposed node group deltas against the per-group min/max sizes and the
+// cluster-wide MaxNodesTotal, MaxCoresTotal and MaxMemoryTotal limits of the CASettingsInfo, and returns
+// the clamped plan along with the reasons for every clamp. Scale-downs are applied first so that the capacity they
+// free is available to scale-ups, and node groups are processed in name order so the result is deterministic. Cores and memory of a node group are taken from the NodeTemplate keyed by the
+// node group name, if present.
+func (c AutoscalerConfig) ClampScalePlan(deltas map[string]int) ScalePlan {
+	plan := ScalePlan{Deltas: make(map[string]int)}
+
+	var totalNodes int
+	var totalCores, totalMemory resource.Quantity
+	for name, ng := range c.NodeGroups {
+		totalNodes += ng.TargetSize
+		cores, memory := c.nodeGroupResources(name, ng.TargetSize)
+		totalCores.Add(cores)
+		totalMemory.Add(memory)
+	}
+
+	names := maps.Keys(deltas)
+	slices.SortFunc(names, func(a, b string) int {
+		if aDown, bDown := deltas[a] < 0, deltas[b] < 0; aDown != bDown {
+			if aDown {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	for _, name := range names {
+		requested := deltas[name]
+		if requested == 0 {
+			continue
+		}
+		ng, ok := c.NodeGroups[name]
+		if !ok {
+			plan.Limits = append(plan.Limits, ScaleLimit{NodeGroupName: name, Requested: requested, Reason: ReasonUnknownNodeGroup})
+			continue
+		}
+		minMax, _ := c.GetMinMax(name)
+		allowed := requested
+		var reason ScaleLimitReason
+		if requested > 0 {
+			if ng.TargetSize+allowed > minMax.Max {
+				allowed, reason = max(minMax.Max-ng.TargetSize, 0), ReasonGroupAtMaxSize
+			}
+			if c.CASettings.MaxNodesTotal > 0 && totalNodes+allowed > c.CASettings.MaxNodesTotal {
+				allowed, reason = max(c.CASettings.MaxNodesTotal-totalNodes, 0), ReasonMaxNodesTotalReached
+			}
+			nodeCores, nodeMemory := c.nodeGroupResources(name, 1)
+			if c.CASettings.MaxCoresTotal > 0 && !nodeCores.IsZero() {
+				maxCores := resource.NewQuantity(int64(c.CASettings.MaxCoresTotal), resource.DecimalSI)
+				if n := remainingNodes(*maxCores, totalCores, nodeCores); n < allowed {
+					allowed, reason = n, ReasonMaxCoresTotalReached
+				}
+			}
+			if !c.CASettings.MaxMemoryTotal.IsZero() && !nodeMemory.IsZero() {
+				if n := remainingNodes(c.CASettings.MaxMemoryTotal, totalMemory, nodeMemory); n < allowed {
+					allowed, reason = n, ReasonMaxMemoryTotalReached
+				}
+			}
+		} else if ng.TargetSize+allowed < minMax.Min {
+			allowed, reason = min(minMax.Min-ng.TargetSize, 0), ReasonBelowMinSize
+		}
+		if reason != "" {
+			plan.Limits = append(plan.Limits, ScaleLimit{NodeGroupName: name, Requested: requested, Allowed: allowed, Reason: reason})
+		}
+		if allowed == 0 {
+			continue
+		}
+		plan.Deltas[name] = allowed
+		totalNodes += allowed
+		cores, memory := c.nodeGroupResources(name, allowed)
+		totalCores.Add(cores)
+		totalMemory.Add(memory)
+	}
+	return plan
+}
+
+// nodeGroupResources returns the cores and memory of count nodes of the given node group.
+func (c AutoscalerConfig) nodeGroupResources(nodeGroupName string, count int) (cores, memory resource.Quantity) {
+	nt, ok := c.NodeTemplates[nodeGroupName]
+	if !ok {
+		return
+	}
+	cores = *resource.NewMilliQuantity(nt.Capacity.Cpu().MilliValue()*int64(count), resource.DecimalSI)
+	memory = *resource.NewQuantity(nt.Capacity.Memory().Value()*int64(count), resource.BinarySI)
+	return
+}
+
+// remainingNodes returns how many nodes of size perNode still fit between used and limit.
+func remainingNodes(limit, used, perNode resource.Quantity) int {
+	free := limit.DeepCopy()
+	free.Sub(used)
+	if free.Sign() <= 0 {
+		return 0
+	}
+	return int(free.MilliValue() / perNode.MilliValue())
+}
@@ -0,0 +1,88 @@
+package gsc
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func newLimitsTestConfig(settings CASettingsInfo) AutoscalerConfig {
+	capacity := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("16Gi"),
+	}
+	return AutoscalerConfig{
+		NodeGroups: map[string]NodeGroupInfo{
+			"a": {Name: "a", MinSize: 1, TargetSize: 2, MaxSize: 10},
+			"b": {Name: "b", MinSize: 1, TargetSize: 3, MaxSize: 10},
+		},
+		NodeTemplates: map[string]NodeTemplate{
+			"a": {Name: "a", Capacity: capacity},
+			"b": {Name: "b", Capacity: capacity},
+		},
+		CASettings: settings,
+	}
+}
+
+func TestClampScalePlan(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   CASettingsInfo
+		deltas     map[string]int
+		wantDeltas map[string]int
+		wantLimits []ScaleLimit
+	}{
+		{
+			name:       "scale-down frees nodes for scale-up",
+			settings:   CASettingsInfo{MaxNodesTotal: 7},
+			deltas:     map[string]int{"a": 4, "b": -2},
+			wantDeltas: map[string]int{"a": 4, "b": -2},
+		},
+		{
+			name:       "scale-up clamped by max nodes total",
+			settings:   CASettingsInfo{MaxNodesTotal: 7},
+			deltas:     map[string]int{"a": 4, "b": -1},
+			wantDeltas: map[string]int{"a": 3, "b": -1},
+			wantLimits: []ScaleLimit{{NodeGroupName: "a", Requested: 4, Allowed: 3, Reason: ReasonMaxNodesTotalReached}},
+		},
+		{
+			name:       "scale-down frees cores for scale-up",
+			settings:   CASettingsInfo{MaxCoresTotal: 24},
+			deltas:     map[string]int{"a": 2, "b": -1},
+			wantDeltas: map[string]int{"a": 2, "b": -1},
+		},
+		{
+			name:       "scale-up clamped by max memory total",
+			settings:   CASettingsInfo{MaxMemoryTotal: resource.MustParse("96Gi")},
+			deltas:     map[string]int{"a": 3},
+			wantDeltas: map[string]int{"a": 1},
+			wantLimits: []ScaleLimit{{NodeGroupName: "a", Requested: 3, Allowed: 1, Reason: ReasonMaxMemoryTotalReached}},
+		},
+		{
+			name:       "group bounds and unknown group",
+			deltas:     map[string]int{"a": 9, "b": -3, "c": 1},
+			wantDeltas: map[string]int{"a": 8, "b": -2},
+			wantLimits: []ScaleLimit{
+				{NodeGroupName: "b", Requested: -3, Allowed: -2, Reason: ReasonBelowMinSize},
+				{NodeGroupName: "a", Requested: 9, Allowed: 8, Reason: ReasonGroupAtMaxSize},
+				{NodeGroupName: "c", Requested: 1, Reason: ReasonUnknownNodeGroup},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := newLimitsTestConfig(tc.settings).ClampScalePlan(tc.deltas)
+			if !maps.Equal(plan.Deltas, tc.wantDeltas) {
+				t.Errorf("expected deltas %v, got %v", tc.wantDeltas, plan.Deltas)
+			}
+			if !slices.Equal(plan.Limits, tc.wantLimits) {
+				t.Errorf("expected limits %v, got %v", tc.wantLimits, plan.Limits)
+			}
+			if plan.IsClamped() != (len(tc.wantLimits) > 0) {
+				t.Errorf("expected IsClamped to be %t", len(tc.wantLimits) > 0)
+			}
+		})
+	}
+}
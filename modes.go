@@ -0,0 +1,156 @@
+package gsc
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AutoscalerReplayerErrorMode is entered by a replaying autoscaler after a failed run. It can only be left by
+// pausing the replay.
+const AutoscalerReplayerErrorMode AutoscalerMode = "replay-mode-error"
+
+var ErrInvalidAutoscalerMode = errors.New("invalid autoscaler mode")
+var ErrInvalidModeTransition = errors.New("invalid autoscaler mode transition")
+
+// AllowedModeTransitions lists the modes each mode may switch to. A standalone autoscaler cannot switch to any replay
+// mode, and a replaying autoscaler alternates between pause and run, entering error on a failed run.
+var AllowedModeTransitions = map[AutoscalerMode][]AutoscalerMode{
+	AutoscalerStandaloneMode:    {},
+	AutoscalerReplayerPauseMode: {AutoscalerReplayerRunMode, AutoscalerReplayerErrorMode},
+	AutoscalerReplayerRunMode:   {AutoscalerReplayerPauseMode, AutoscalerReplayerErrorMode},
+	AutoscalerReplayerErrorMode: {AutoscalerReplayerPauseMode},
+}
+
+// ParseAutoscalerMode validates the given mode string.
+func ParseAutoscalerMode(mode string) (AutoscalerMode, error) {
+	if _, ok := AllowedModeTransitions[AutoscalerMode(mode)]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidAutoscalerMode, mode)
+	}
+	return AutoscalerMode(mode), nil
+}
+
+// CanTransitionTo returns true if the mode may switch to the target mode. Staying in the same mode is always allowed.
+func (m AutoscalerMode) CanTransitionTo(target AutoscalerMode) bool {
+	return m == target || slices.Contains(AllowedModeTransitions[m], target)
+}
+
+// ModeTransition records a single switch between autoscaler modes.
+type ModeTransition struct {
+	From   AutoscalerMode `json:"from"`
+	To     AutoscalerMode `json:"to"`
+	Time   time.Time      `json:"time"`
+	Reason string         `json:"reason,omitempty"`
+}
+
+func (t ModeTransition) String() string {
+	return fmt.Sprintf("ModeTransition(From=%s, To=%s, Time=%s, Reason=%s)", t.From, t.To, t.Time, t.Reason)
+}
+
+// ModeTransitionHook is invoked after every successful transition. It must not transition the machine itself.
+type ModeTransitionHook func(t ModeTransition)
+
+// AutoscalerModeMachine holds the current AutoscalerMode, validates transitions against AllowedModeTransitions,
+// invokes hooks on every transition and records the transition history. It is safe for concurrent use.
+type AutoscalerModeMachine struct {
+	mu      sync.Mutex
+	current AutoscalerMode
+	history []ModeTransition
+	hooks   []ModeTransitionHook
+	// pending holds the transitions whose hooks have not been invoked yet.
+	pending []ModeTransition
+	// hookMu is held while invoking hooks, so that hooks see transitions in the order they happened.
+	hookMu sync.Mutex
+}
+
+// NewAutoscalerModeMachine creates an AutoscalerModeMachine starting in the given mode.
+func NewAutoscalerModeMachine(initial AutoscalerMode) (*AutoscalerModeMachine, error) {
+	if _, err := ParseAutoscalerMode(string(initial)); err != nil {
+		return nil, err
+	}
+	return &AutoscalerModeMachine{current: initial}, nil
+}
+
+// OnTransition registers a hook invoked after every transition. Hooks are invoked in the order of the transitions,
+// without holding the lock of the machine, so they may call Current and History.
+func (m *AutoscalerModeMachine) OnTransition(hook ModeTransitionHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Current returns the current mode.
+func (m *AutoscalerModeMachine) Current() AutoscalerMode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current
+}
+
+// History returns a copy of the recorded transitions in the order they happened.
+func (m *AutoscalerModeMachine) History() []ModeTransition {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.history)
+}
+
+// Transition switches to the target mode if allowed, recording the transition with the given reason. Switching to
+// the current mode is a no-op that is neither recorded nor passed to hooks.
+func (m *AutoscalerModeMachine) Transition(target AutoscalerMode, reason string) error {
+	return m.TransitionWith(target, reason, nil)
+}
+
+// TransitionWith is like Transition but invokes apply, if non-nil, while holding the lock of the machine after the
+// transition has been validated, so that no other transition can interleave. If apply fails, the mode is left
+// unchanged and the error of apply is returned. apply is not invoked when switching to the current mode.
+func (m *AutoscalerModeMachine) TransitionWith(target AutoscalerMode, reason string, apply func() error) error {
+	if _, err := ParseAutoscalerMode(string(target)); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	if m.current == target {
+		m.mu.Unlock()
+		return nil
+	}
+	if !m.current.CanTransitionTo(target) {
+		current := m.current
+		m.mu.Unlock()
+		return fmt.Errorf("%w: from %q to %q", ErrInvalidModeTransition, current, target)
+	}
+	if apply != nil {
+		if err := apply(); err != nil {
+			m.mu.Unlock()
+			return err
+		}
+	}
+	t := ModeTransition{From: m.current, To: target, Time: time.Now().UTC(), Reason: reason}
+	m.current = target
+	m.history = append(m.history, t)
+	m.pending = append(m.pending, t)
+	m.mu.Unlock()
+	m.invokeHooks()
+	return nil
+}
+
+// invokeHooks passes the pending transitions to the hooks in the order they happened. Whoever holds hookMu invokes
+// the hooks for all pending transitions, including those of concurrent callers, so every transition has been passed
+// to the hooks once invokeHooks returns.
+func (m *AutoscalerModeMachine) invokeHooks() {
+	m.hookMu.Lock()
+	defer m.hookMu.Unlock()
+	for {
+		m.mu.Lock()
+		if len(m.pending) == 0 {
+			m.mu.Unlock()
+			return
+		}
+		t := m.pending[0]
+		m.pending = m.pending[1:]
+		hooks := slices.Clone(m.hooks)
+		m.mu.Unlock()
+		for _, hook := range hooks {
+			hook(t)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package gsc
+
+import (
+	"errors"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestAutoscalerModeMachineTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    AutoscalerMode
+		to      AutoscalerMode
+		wantErr error
+	}{
+		{name: "pause to run", from: AutoscalerReplayerPauseMode, to: AutoscalerReplayerRunMode},
+		{name: "run to pause", from: AutoscalerReplayerRunMode, to: AutoscalerReplayerPauseMode},
+		{name: "run to error", from: AutoscalerReplayerRunMode, to: AutoscalerReplayerErrorMode},
+		{name: "error to pause", from: AutoscalerReplayerErrorMode, to: AutoscalerReplayerPauseMode},
+		{name: "error to run", from: AutoscalerReplayerErrorMode, to: AutoscalerReplayerRunMode, wantErr: ErrInvalidModeTransition},
+		{name: "standalone to run", from: AutoscalerStandaloneMode, to: AutoscalerReplayerRunMode, wantErr: ErrInvalidModeTransition},
+		{name: "pause to standalone", from: AutoscalerReplayerPauseMode, to: AutoscalerStandaloneMode, wantErr: ErrInvalidModeTransition},
+		{name: "unknown target", from: AutoscalerReplayerPauseMode, to: "replay-mode-fast", wantErr: ErrInvalidAutoscalerMode},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := NewAutoscalerModeMachine(tc.from)
+			if err != nil {
+				t.Fatalf("cannot create mode machine: %v", err)
+			}
+			var hooked []ModeTransition
+			m.OnTransition(func(t ModeTransition) { hooked = append(hooked, t) })
+
+			err = m.Transition(tc.to, "test")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			wantMode, wantHistory := tc.to, 1
+			if tc.wantErr != nil {
+				wantMode, wantHistory = tc.from, 0
+			}
+			if m.Current() != wantMode {
+				t.Errorf("expected mode %s, got %s", wantMode, m.Current())
+			}
+			history := m.History()
+			if len(history) != wantHistory || len(hooked) != wantHistory {
+				t.Fatalf("expected %d transitions in history and hooks, got %v and %v", wantHistory, history, hooked)
+			}
+			if wantHistory > 0 && (history[0].From != tc.from || history[0].To != tc.to || history[0].Reason != "test" || history[0] != hooked[0]) {
+				t.Errorf("expected transition from %s to %s, got %s and hooked %s", tc.from, tc.to, history[0], hooked[0])
+			}
+		})
+	}
+}
+
+func TestNewAutoscalerModeMachineRejectsUnknownMode(t *testing.T) {
+	if _, err := NewAutoscalerModeMachine("replay-mode-fast"); !errors.Is(err, ErrInvalidAutoscalerMode) {
+		t.Errorf("expected ErrInvalidAutoscalerMode, got %v", err)
+	}
+}
+
+func TestAutoscalerModeMachineSameModeIsNoop(t *testing.T) {
+	m, err := NewAutoscalerModeMachine(AutoscalerStandaloneMode)
+	if err != nil {
+		t.Fatalf("cannot create mode machine: %v", err)
+	}
+	var hooks, applies int
+	m.OnTransition(func(ModeTransition) { hooks++ })
+	err = m.TransitionWith(AutoscalerStandaloneMode, "test", func() error {
+		applies++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected transition to the current mode to succeed, got %v", err)
+	}
+	if hooks != 0 || applies != 0 || len(m.History()) != 0 {
+		t.Errorf("expected no hooks, applies or history, got %d hooks, %d applies and history %v", hooks, applies, m.History())
+	}
+}
+
+func TestAutoscalerModeMachineTransitionWithFailingApply(t *testing.T) {
+	m, err := NewAutoscalerModeMachine(AutoscalerReplayerPauseMode)
+	if err != nil {
+		t.Fatalf("cannot create mode machine: %v", err)
+	}
+	applyErr := errors.New("cannot switch")
+	if err = m.TransitionWith(AutoscalerReplayerRunMode, "test", func() error { return applyErr }); !errors.Is(err, applyErr) {
+		t.Fatalf("expected error of apply, got %v", err)
+	}
+	if m.Current() != AutoscalerReplayerPauseMode || len(m.History()) != 0 {
+		t.Errorf("expected mode to stay %s without history, got %s and %v", AutoscalerReplayerPauseMode, m.Current(), m.History())
+	}
+}
+
+func TestAutoscalerModeMachineInvokesHooksInOrder(t *testing.T) {
+	m, err := NewAutoscalerModeMachine(AutoscalerReplayerPauseMode)
+	if err != nil {
+		t.Fatalf("cannot create mode machine: %v", err)
+	}
+	var hooked []ModeTransition
+	var lastMode AutoscalerMode
+	m.OnTransition(func(t ModeTransition) {
+		// yield to widen the window for concurrent transitions to overtake each other.
+		runtime.Gosched()
+		hooked = append(hooked, t)
+		lastMode = t.To
+	})
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			targets := []AutoscalerMode{AutoscalerReplayerRunMode, AutoscalerReplayerPauseMode}
+			for j := range 50 {
+				_ = m.Transition(targets[(i+j)%2], "test")
+			}
+		}()
+	}
+	wg.Wait()
+
+	history := m.History()
+	if len(history) == 0 {
+		t.Fatal("expected transitions to be recorded")
+	}
+	if !slices.Equal(hooked, history) {
+		t.Errorf("expected hooks to see the %d transitions of the history in the same order", len(history))
+	}
+	for i := 1; i < len(history); i++ {
+		if history[i].From != history[i-1].To {
+			t.Fatalf("expected transition %d to start from %s, got %s", i, history[i-1].To, history[i])
+		}
+	}
+	if lastMode != m.Current() {
+		t.Errorf("expected last hooked mode %s to be the current mode %s", lastMode, m.Current())
+	}
+}
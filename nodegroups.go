@@ -0,0 +1,90 @@
+package gsc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ToNodeGroups expands the worker pool into one NodeGroupInfo per zone. The Minimum and Maximum of the pool are
+// distributed over the zones using DistributeOverZones and the groups are named like the MCM MachineDeployments
+// using GetNodeGroupName with the pool Namespace as technical ID. The TargetSize of every group is its MinSize.
+func (w WorkerPoolInfo) ToNodeGroups() []NodeGroupInfo {
+	nodeGroups := make([]NodeGroupInfo, 0, len(w.Zones))
+	for zoneIndex, zone := range w.Zones {
+		ng := NodeGroupInfo{
+			Name:     GetNodeGroupName(w.Namespace, w.Name, zoneIndex),
+			PoolName: w.Name,
+			Zone:     zone,
+			MinSize:  DistributeOverZones(zoneIndex, w.Minimum, len(w.Zones)),
+			MaxSize:  DistributeOverZones(zoneIndex, w.Maximum, len(w.Zones)),
+		}
+		ng.TargetSize = ng.MinSize
+		ng.Hash = ng.GetHash()
+		nodeGroups = append(nodeGroups, ng)
+	}
+	return nodeGroups
+}
+
+type NodeGroupMismatchReason string
+
+const (
+	MismatchMissingMachineDeployment    NodeGroupMismatchReason = "missing machine deployment"
+	MismatchUnexpectedMachineDeployment NodeGroupMismatchReason = "unexpected machine deployment"
+	MismatchZone                        NodeGroupMismatchReason = "zone differs"
+	MismatchPoolName                    NodeGroupMismatchReason = "pool name differs"
+	MismatchReplicasBelowMin            NodeGroupMismatchReason = "replicas below min size"
+	MismatchReplicasAboveMax            NodeGroupMismatchReason = "replicas above max size"
+)
+
+// NodeGroupMismatch is a difference between an expected NodeGroupInfo and the observed MachineDeploymentInfo of the same name.
+type NodeGroupMismatch struct {
+	NodeGroupName string
+	Reason        NodeGroupMismatchReason
+	Expected      string
+	Observed      string
+}
+
+func (m NodeGroupMismatch) String() string {
+	return fmt.Sprintf("NodeGroupMismatch(NodeGroupName=%s, Reason=%s, Expected=%s, Observed=%s)", m.NodeGroupName, m.Reason, m.Expected, m.Observed)
+}
+
+// CheckNodeGroups cross-checks the given node groups against the observed machine deployments and reports
+// machine deployments that are missing or unexpected, as well as pool, zone and replica mismatches. Machine
+// deployments that are being deleted are ignored. The result is sorted by node group name.
+func CheckNodeGroups(nodeGroups []NodeGroupInfo, mcdInfos []MachineDeploymentInfo) []NodeGroupMismatch {
+	var mismatches []NodeGroupMismatch
+	mcdsByName := make(map[string]MachineDeploymentInfo, len(mcdInfos))
+	for _, mcd := range mcdInfos {
+		if mcd.DeletionTimestamp.IsZero() {
+			mcdsByName[mcd.Name] = mcd
+		}
+	}
+	for _, ng := range nodeGroups {
+		mcd, ok := mcdsByName[ng.Name]
+		if !ok {
+			mismatches = append(mismatches, NodeGroupMismatch{NodeGroupName: ng.Name, Reason: MismatchMissingMachineDeployment})
+			continue
+		}
+		delete(mcdsByName, ng.Name)
+		if mcd.PoolName != "" && mcd.PoolName != ng.PoolName {
+			mismatches = append(mismatches, NodeGroupMismatch{NodeGroupName: ng.Name, Reason: MismatchPoolName, Expected: ng.PoolName, Observed: mcd.PoolName})
+		}
+		if mcd.Zone != "" && mcd.Zone != ng.Zone {
+			mismatches = append(mismatches, NodeGroupMismatch{NodeGroupName: ng.Name, Reason: MismatchZone, Expected: ng.Zone, Observed: mcd.Zone})
+		}
+		if mcd.Replicas < ng.MinSize {
+			mismatches = append(mismatches, NodeGroupMismatch{NodeGroupName: ng.Name, Reason: MismatchReplicasBelowMin, Expected: fmt.Sprintf(">=%d", ng.MinSize), Observed: fmt.Sprint(mcd.Replicas)})
+		}
+		if mcd.Replicas > ng.MaxSize {
+			mismatches = append(mismatches, NodeGroupMismatch{NodeGroupName: ng.Name, Reason: MismatchReplicasAboveMax, Expected: fmt.Sprintf("<=%d", ng.MaxSize), Observed: fmt.Sprint(mcd.Replicas)})
+		}
+	}
+	for name := range mcdsByName {
+		mismatches = append(mismatches, NodeGroupMismatch{NodeGroupName: name, Reason: MismatchUnexpectedMachineDeployment})
+	}
+	slices.SortStableFunc(mismatches, func(a, b NodeGroupMismatch) int {
+		return strings.Compare(a.NodeGroupName, b.NodeGroupName)
+	})
+	return mismatches
+}
@@ -0,0 +1,85 @@
+package gsc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDistributeOverZones(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		numZones int
+		want     []int
+	}{
+		{name: "even", size: 6, numZones: 3, want: []int{2, 2, 2}},
+		{name: "remainder to first zones", size: 7, numZones: 3, want: []int{3, 2, 2}},
+		{name: "remainder of two", size: 8, numZones: 3, want: []int{3, 3, 2}},
+		{name: "fewer than zones", size: 2, numZones: 3, want: []int{1, 1, 0}},
+		{name: "zero", size: 0, numZones: 2, want: []int{0, 0}},
+		{name: "single zone", size: 5, numZones: 1, want: []int{5}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := make([]int, tc.numZones)
+			var sum int
+			for i := range got {
+				got[i] = DistributeOverZones(i, tc.size, tc.numZones)
+				sum += got[i]
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+			if sum != tc.size {
+				t.Errorf("expected shares to add up to %d, got %d", tc.size, sum)
+			}
+		})
+	}
+	if got := DistributeOverZones(0, 3, 0); got != 0 {
+		t.Errorf("expected 0 without zones, got %d", got)
+	}
+}
+
+func TestGetNodeGroupName(t *testing.T) {
+	tests := []struct {
+		technicalID string
+		poolName    string
+		zoneIndex   int
+		want        string
+	}{
+		{technicalID: "shoot--dev--aws", poolName: "a", zoneIndex: 0, want: "shoot--dev--aws-a-z1"},
+		{technicalID: "shoot--dev--aws", poolName: "worker-gpu", zoneIndex: 2, want: "shoot--dev--aws-worker-gpu-z3"},
+	}
+	for _, tc := range tests {
+		if got := GetNodeGroupName(tc.technicalID, tc.poolName, tc.zoneIndex); got != tc.want {
+			t.Errorf("expected node group name %q, got %q", tc.want, got)
+		}
+	}
+}
+
+func TestWorkerPoolInfoToNodeGroups(t *testing.T) {
+	pool := WorkerPoolInfo{
+		SnapshotMeta: SnapshotMeta{Name: "a", Namespace: "shoot--dev--aws"},
+		Minimum:      4,
+		Maximum:      8,
+		Zones:        []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"},
+	}
+	got := pool.ToNodeGroups()
+	want := []NodeGroupInfo{
+		{Name: "shoot--dev--aws-a-z1", PoolName: "a", Zone: "eu-west-1a", MinSize: 2, TargetSize: 2, MaxSize: 3},
+		{Name: "shoot--dev--aws-a-z2", PoolName: "a", Zone: "eu-west-1b", MinSize: 1, TargetSize: 1, MaxSize: 3},
+		{Name: "shoot--dev--aws-a-z3", PoolName: "a", Zone: "eu-west-1c", MinSize: 1, TargetSize: 1, MaxSize: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d node groups, got %d", len(want), len(got))
+	}
+	for i, ng := range got {
+		if ng.Hash == "" {
+			t.Errorf("expected hash of node group %q to be set", ng.Name)
+		}
+		ng.Hash = ""
+		if ng != want[i] {
+			t.Errorf("expected node group %s, got %s", want[i], ng)
+		}
+	}
+}
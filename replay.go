@@ -0,0 +1,222 @@
+package gsc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ReplaySkipReason describes why the ReplayDriver did not execute a snapshot.
+type ReplaySkipReason string
+
+const (
+	ReplaySkipNoUnscheduledPods   ReplaySkipReason = "NoUnscheduledPods"
+	ReplaySkipSameUnscheduledPods ReplaySkipReason = "SameUnscheduledPods"
+)
+
+// SnapshotSource provides ClusterSnapshots in replay order. Next returns false once the source is exhausted.
+type SnapshotSource interface {
+	Next(ctx context.Context) (snapshot ClusterSnapshot, ok bool, err error)
+}
+
+type sliceSnapshotSource struct {
+	snapshots []ClusterSnapshot
+	next      int
+}
+
+// NewSliceSnapshotSource creates a SnapshotSource that provides the given snapshots ordered by SnapshotTime.
+func NewSliceSnapshotSource(snapshots []ClusterSnapshot) SnapshotSource {
+	snapshots = slices.Clone(snapshots)
+	slices.SortStableFunc(snapshots, func(a, b ClusterSnapshot) int {
+		return a.SnapshotTime.Compare(b.SnapshotTime)
+	})
+	return &sliceSnapshotSource{snapshots: snapshots}
+}
+
+func (s *sliceSnapshotSource) Next(_ context.Context) (snapshot ClusterSnapshot, ok bool, err error) {
+	if s.next >= len(s.snapshots) {
+		return
+	}
+	snapshot, ok = s.snapshots[s.next], true
+	s.next++
+	return
+}
+
+// ReplayExecutor runs the virtual autoscaler for a single replay step.
+type ReplayExecutor interface {
+	// Apply hands the config and the snapshot it was derived from to the autoscaler.
+	Apply(ctx context.Context, snapshot ClusterSnapshot, config AutoscalerConfig) error
+	// WaitForCompletion blocks until the autoscaler has finished the run for the given config.
+	WaitForCompletion(ctx context.Context, config AutoscalerConfig) error
+}
+
+// DeriveAutoscalerConfig returns the AutoscalerConfig of the snapshot with its nodes as ExistingNodes, the
+// AutoscalerReplayerRunMode as Mode and its Hash recomputed.
+func DeriveAutoscalerConfig(snapshot ClusterSnapshot) (AutoscalerConfig, error) {
+	config := snapshot.AutoscalerConfig
+	if len(config.NodeGroups) == 0 {
+		return config, fmt.Errorf("cannot derive autoscaler config of snapshot %q: no node groups", snapshot.ID)
+	}
+	config.ExistingNodes = slices.Clone(snapshot.Nodes)
+	config.Mode = AutoscalerReplayerRunMode
+	config.Hash = config.GetHash()
+	return config, nil
+}
+
+// ReplayStepResult is the outcome of replaying a single snapshot.
+type ReplayStepResult struct {
+	SnapshotID      string           `json:"snapshotID"`
+	SnapshotNumber  int              `json:"snapshotNumber"`
+	SnapshotTime    time.Time        `json:"snapshotTime"`
+	ConfigHash      string           `json:"configHash,omitempty"`
+	UnscheduledPods int              `json:"unscheduledPods"`
+	Skipped         bool             `json:"skipped"`
+	SkipReason      ReplaySkipReason `json:"skipReason,omitempty"`
+	StartTime       time.Time        `json:"startTime"`
+	EndTime         time.Time        `json:"endTime"`
+	Error           string           `json:"error,omitempty"`
+}
+
+func (r ReplayStepResult) String() string {
+	return fmt.Sprintf("ReplayStepResult(SnapshotID=%s, SnapshotNumber=%d, SnapshotTime=%s, ConfigHash=%s, UnscheduledPods=%d, Skipped=%t, SkipReason=%s, Duration=%s, Error=%s)",
+		r.SnapshotID, r.SnapshotNumber, r.SnapshotTime, r.ConfigHash, r.UnscheduledPods, r.Skipped, r.SkipReason, r.EndTime.Sub(r.StartTime), r.Error)
+}
+
+// ReplayReport collects the step results and the mode transitions of a replay.
+type ReplayReport struct {
+	StartTime   time.Time          `json:"startTime"`
+	EndTime     time.Time          `json:"endTime"`
+	Steps       []ReplayStepResult `json:"steps"`
+	ModeHistory []ModeTransition   `json:"modeHistory"`
+}
+
+// GetExecutedSteps returns the steps that were not skipped.
+func (r ReplayReport) GetExecutedSteps() []ReplayStepResult {
+	return slices.DeleteFunc(slices.Clone(r.Steps), func(s ReplayStepResult) bool {
+		return s.Skipped
+	})
+}
+
+// GetFailedSteps returns the steps whose execution failed.
+func (r ReplayReport) GetFailedSteps() []ReplayStepResult {
+	return slices.DeleteFunc(slices.Clone(r.Steps), func(s ReplayStepResult) bool {
+		return s.Error == ""
+	})
+}
+
+// ReplayDriver replays the snapshots of a SnapshotSource through a ReplayExecutor. A snapshot is only executed if it
+// has unscheduled pods that differ from those of the last successfully executed snapshot.
+type ReplayDriver struct {
+	Source   SnapshotSource
+	Executor ReplayExecutor
+	// DeriveConfig derives the config of a snapshot. DeriveAutoscalerConfig is used if nil.
+	DeriveConfig func(snapshot ClusterSnapshot) (AutoscalerConfig, error)
+	// StepTimeout bounds the Apply and WaitForCompletion of a single step. No timeout is applied if zero or less.
+	StepTimeout time.Duration
+	// ContinueOnError continues with the next snapshot after a failed step instead of stopping the replay.
+	ContinueOnError bool
+}
+
+// Run replays all snapshots of the source and returns the report of all steps done so far, even on error. The
+// autoscaler mode is tracked with an AutoscalerModeMachine that switches to run for every executed step, back to
+// pause once it is done and to error if it failed; its history is part of the report.
+func (d ReplayDriver) Run(ctx context.Context) (report ReplayReport, err error) {
+	if d.Source == nil || d.Executor == nil {
+		return report, errors.New("cannot run replay: source and executor must be set")
+	}
+	deriveConfig := d.DeriveConfig
+	if deriveConfig == nil {
+		deriveConfig = DeriveAutoscalerConfig
+	}
+	modes, err := NewAutoscalerModeMachine(AutoscalerReplayerPauseMode)
+	if err != nil {
+		return
+	}
+	report.StartTime = time.Now().UTC()
+	defer func() {
+		report.EndTime = time.Now().UTC()
+		report.ModeHistory = modes.History()
+	}()
+
+	var lastExecuted *ClusterSnapshot
+	var errs []error
+	for {
+		var snapshot ClusterSnapshot
+		var ok bool
+		snapshot, ok, err = d.Source.Next(ctx)
+		if err != nil {
+			err = fmt.Errorf("cannot get next snapshot after %d steps: %w", len(report.Steps), err)
+			return
+		}
+		if !ok {
+			break
+		}
+		step := ReplayStepResult{
+			SnapshotID:      snapshot.ID,
+			SnapshotNumber:  snapshot.Number,
+			SnapshotTime:    snapshot.SnapshotTime,
+			UnscheduledPods: len(snapshot.GetPodsWithScheduleStatus(PodUnscheduled)),
+			StartTime:       time.Now().UTC(),
+		}
+		switch {
+		case step.UnscheduledPods == 0:
+			step.Skipped, step.SkipReason = true, ReplaySkipNoUnscheduledPods
+		case lastExecuted != nil && snapshot.HasSameUnscheduledPods(*lastExecuted):
+			step.Skipped, step.SkipReason = true, ReplaySkipSameUnscheduledPods
+		}
+		if step.Skipped {
+			step.EndTime = step.StartTime
+			report.Steps = append(report.Steps, step)
+			continue
+		}
+		stepErr := d.executeStep(ctx, snapshot, deriveConfig, modes, &step)
+		step.EndTime = time.Now().UTC()
+		report.Steps = append(report.Steps, step)
+		if stepErr == nil {
+			lastExecuted = &snapshot
+			continue
+		}
+		stepErr = fmt.Errorf("cannot replay snapshot %q (number %d): %w", snapshot.ID, snapshot.Number, stepErr)
+		if !d.ContinueOnError || ctx.Err() != nil {
+			err = errors.Join(append(errs, stepErr)...)
+			return
+		}
+		errs = append(errs, stepErr)
+	}
+	err = errors.Join(errs...)
+	return
+}
+
+func (d ReplayDriver) executeStep(ctx context.Context, snapshot ClusterSnapshot, deriveConfig func(ClusterSnapshot) (AutoscalerConfig, error), modes *AutoscalerModeMachine, step *ReplayStepResult) (err error) {
+	config, err := deriveConfig(snapshot)
+	if err != nil {
+		step.Error = err.Error()
+		return
+	}
+	step.ConfigHash = config.Hash
+	if err = modes.Transition(AutoscalerReplayerRunMode, fmt.Sprintf("replay snapshot %q", snapshot.ID)); err != nil {
+		step.Error = err.Error()
+		return
+	}
+	defer func() {
+		if err != nil {
+			step.Error = err.Error()
+			_ = modes.Transition(AutoscalerReplayerErrorMode, err.Error())
+		}
+		_ = modes.Transition(AutoscalerReplayerPauseMode, fmt.Sprintf("replayed snapshot %q", snapshot.ID))
+	}()
+	if d.StepTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.StepTimeout)
+		defer cancel()
+	}
+	if err = d.Executor.Apply(ctx, snapshot, config); err != nil {
+		return fmt.Errorf("cannot apply config %q: %w", config.Hash, err)
+	}
+	if err = d.Executor.WaitForCompletion(ctx, config); err != nil {
+		return fmt.Errorf("cannot complete run of config %q: %w", config.Hash, err)
+	}
+	return
+}
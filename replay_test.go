@@ -0,0 +1,193 @@
+package gsc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+type fakeReplayExecutor struct {
+	// failures holds the error to fail WaitForCompletion with per snapshot ID.
+	failures map[string]error
+	applied  []string
+	current  string
+}
+
+func (e *fakeReplayExecutor) Apply(_ context.Context, snapshot ClusterSnapshot, config AutoscalerConfig) error {
+	if config.Mode != AutoscalerReplayerRunMode || len(config.ExistingNodes) != len(snapshot.Nodes) {
+		return errors.New("unexpected config")
+	}
+	e.applied = append(e.applied, snapshot.ID)
+	e.current = snapshot.ID
+	return nil
+}
+
+func (e *fakeReplayExecutor) WaitForCompletion(_ context.Context, _ AutoscalerConfig) error {
+	return e.failures[e.current]
+}
+
+// newReplayTestSnapshot creates a snapshot with a scheduled pod and the given unscheduled pods.
+func newReplayTestSnapshot(number int, unscheduledPods ...string) ClusterSnapshot {
+	snapshot := ClusterSnapshot{
+		ID:           fmt.Sprintf("s%d", number),
+		Number:       number,
+		SnapshotTime: time.Date(2024, 7, 1, 10, number, 0, 0, time.UTC),
+		AutoscalerConfig: AutoscalerConfig{
+			NodeGroups: map[string]NodeGroupInfo{"ng": {Name: "ng", MinSize: 1, TargetSize: 1, MaxSize: 3}},
+		},
+		Nodes: []NodeInfo{{SnapshotMeta: SnapshotMeta{Name: "node-1"}}},
+		Pods:  []PodInfo{{SnapshotMeta: SnapshotMeta{Name: "scheduled"}, UID: "uid-scheduled", NodeName: "node-1", PodScheduleStatus: PodScheduleCommited}},
+	}
+	for _, name := range unscheduledPods {
+		snapshot.Pods = append(snapshot.Pods, PodInfo{SnapshotMeta: SnapshotMeta{Name: name}, UID: "uid-" + name, PodScheduleStatus: PodUnscheduled})
+	}
+	return snapshot
+}
+
+type replayTestStep struct {
+	SnapshotID string
+	SkipReason ReplaySkipReason
+	Failed     bool
+}
+
+func TestReplayDriverRun(t *testing.T) {
+	const (
+		pause = AutoscalerReplayerPauseMode
+		run   = AutoscalerReplayerRunMode
+		fail  = AutoscalerReplayerErrorMode
+	)
+	tests := []struct {
+		name            string
+		snapshots       []ClusterSnapshot
+		failures        map[string]error
+		continueOnError bool
+		wantApplied     []string
+		wantSteps       []replayTestStep
+		wantModes       []AutoscalerMode
+		wantErr         bool
+	}{
+		{
+			name: "skips snapshots without new unscheduled pods",
+			snapshots: []ClusterSnapshot{
+				newReplayTestSnapshot(4, "a", "b"),
+				newReplayTestSnapshot(1),
+				newReplayTestSnapshot(3, "a"),
+				newReplayTestSnapshot(2, "a"),
+			},
+			wantApplied: []string{"s2", "s4"},
+			wantSteps: []replayTestStep{
+				{SnapshotID: "s1", SkipReason: ReplaySkipNoUnscheduledPods},
+				{SnapshotID: "s2"},
+				{SnapshotID: "s3", SkipReason: ReplaySkipSameUnscheduledPods},
+				{SnapshotID: "s4"},
+			},
+			wantModes: []AutoscalerMode{run, pause, run, pause},
+		},
+		{
+			name: "stops at first failure",
+			snapshots: []ClusterSnapshot{
+				newReplayTestSnapshot(1, "a"),
+				newReplayTestSnapshot(2, "b"),
+			},
+			failures:    map[string]error{"s1": errors.New("node group not found")},
+			wantApplied: []string{"s1"},
+			wantSteps:   []replayTestStep{{SnapshotID: "s1", Failed: true}},
+			wantModes:   []AutoscalerMode{run, fail, pause},
+			wantErr:     true,
+		},
+		{
+			name: "retries unscheduled pods of failed snapshot when continuing on error",
+			snapshots: []ClusterSnapshot{
+				newReplayTestSnapshot(1, "a"),
+				newReplayTestSnapshot(2, "a"),
+				newReplayTestSnapshot(3, "a"),
+			},
+			failures:        map[string]error{"s1": errors.New("node group not found")},
+			continueOnError: true,
+			wantApplied:     []string{"s1", "s2"},
+			wantSteps: []replayTestStep{
+				{SnapshotID: "s1", Failed: true},
+				{SnapshotID: "s2"},
+				{SnapshotID: "s3", SkipReason: ReplaySkipSameUnscheduledPods},
+			},
+			wantModes: []AutoscalerMode{run, fail, pause, run, pause},
+			wantErr:   true,
+		},
+		{
+			name: "snapshot without node groups",
+			snapshots: []ClusterSnapshot{
+				func() ClusterSnapshot {
+					s := newReplayTestSnapshot(1, "a")
+					s.AutoscalerConfig.NodeGroups = nil
+					return s
+				}(),
+				newReplayTestSnapshot(2, "a"),
+			},
+			continueOnError: true,
+			wantApplied:     []string{"s2"},
+			wantSteps: []replayTestStep{
+				{SnapshotID: "s1", Failed: true},
+				{SnapshotID: "s2"},
+			},
+			wantModes: []AutoscalerMode{run, pause},
+			wantErr:   true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			executor := &fakeReplayExecutor{failures: tc.failures}
+			driver := ReplayDriver{
+				Source:          NewSliceSnapshotSource(tc.snapshots),
+				Executor:        executor,
+				ContinueOnError: tc.continueOnError,
+			}
+			report, err := driver.Run(context.Background())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %t, got %v", tc.wantErr, err)
+			}
+			for _, failure := range tc.failures {
+				if !errors.Is(err, failure) {
+					t.Errorf("expected error to wrap %v, got %v", failure, err)
+				}
+			}
+			if !slices.Equal(executor.applied, tc.wantApplied) {
+				t.Errorf("expected applied snapshots %v, got %v", tc.wantApplied, executor.applied)
+			}
+			steps := make([]replayTestStep, 0, len(report.Steps))
+			for _, s := range report.Steps {
+				steps = append(steps, replayTestStep{SnapshotID: s.SnapshotID, SkipReason: s.SkipReason, Failed: s.Error != ""})
+				if s.Skipped != (s.SkipReason != "") {
+					t.Errorf("expected step %q to be skipped only with a skip reason, got %s", s.SnapshotID, s)
+				}
+			}
+			if !slices.Equal(steps, tc.wantSteps) {
+				t.Errorf("expected steps %v, got %v", tc.wantSteps, steps)
+			}
+			modes := make([]AutoscalerMode, 0, len(report.ModeHistory))
+			for _, mt := range report.ModeHistory {
+				modes = append(modes, mt.To)
+			}
+			if !slices.Equal(modes, tc.wantModes) {
+				t.Errorf("expected mode transitions to %v, got %v", tc.wantModes, modes)
+			}
+		})
+	}
+}
+
+func TestDeriveAutoscalerConfigDoesNotAliasNodes(t *testing.T) {
+	snapshot := newReplayTestSnapshot(1, "a")
+	config, err := DeriveAutoscalerConfig(snapshot)
+	if err != nil {
+		t.Fatalf("cannot derive autoscaler config: %v", err)
+	}
+	if config.Mode != AutoscalerReplayerRunMode || config.Hash != config.GetHash() {
+		t.Errorf("expected config in run mode with its hash, got mode %s and hash %q", config.Mode, config.Hash)
+	}
+	config.ExistingNodes[0].Name = "changed"
+	if snapshot.Nodes[0].Name != "node-1" {
+		t.Errorf("expected nodes of snapshot to be unchanged, got %q", snapshot.Nodes[0].Name)
+	}
+}
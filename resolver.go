@@ -0,0 +1,145 @@
+package gsc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// NodeGroupResolver maps nodes to the node groups they belong to. A node is first resolved through the MCM
+// Machine having its ProviderID, whose MachineSet is owned by the MachineDeployment named like the node group,
+// and otherwise through its pool and zone labels.
+type NodeGroupResolver struct {
+	nodeGroups map[string]NodeGroupInfo
+	// groupsByPoolZone holds the node group name per poolZoneKey.
+	groupsByPoolZone map[string]string
+	// groupsByProviderID holds the node group name per machine provider ID.
+	groupsByProviderID map[string]string
+	machines           []MachineInfo
+	// groupsByMachineSet holds the node group name per machine set name.
+	groupsByMachineSet map[string]string
+}
+
+// NodeGroupTargetSizeMismatch reports a node group whose TargetSize differs from the number of registered nodes.
+type NodeGroupTargetSizeMismatch struct {
+	NodeGroupName   string
+	TargetSize      int
+	RegisteredNodes int
+}
+
+// UnregisteredMachine is a machine of a node group whose node has not registered within the max node provision time.
+type UnregisteredMachine struct {
+	NodeGroupName string
+	Machine       MachineInfo
+	Age           time.Duration
+}
+
+// NodeResolutionReport is the result of NodeGroupResolver.Analyze.
+type NodeResolutionReport struct {
+	NodesByGroup         map[string][]NodeInfo
+	OrphanedNodes        []NodeInfo
+	TargetSizeMismatches []NodeGroupTargetSizeMismatch
+	UnregisteredMachines []UnregisteredMachine
+}
+
+func (m NodeGroupTargetSizeMismatch) String() string {
+	return fmt.Sprintf("NodeGroupTargetSizeMismatch(NodeGroupName=%s, TargetSize=%d, RegisteredNodes=%d)", m.NodeGroupName, m.TargetSize, m.RegisteredNodes)
+}
+
+func (u UnregisteredMachine) String() string {
+	return fmt.Sprintf("UnregisteredMachine(NodeGroupName=%s, Machine=%s, ProviderID=%s, Age=%s)", u.NodeGroupName, u.Machine.Name, u.Machine.ProviderID, u.Age)
+}
+
+// NewNodeGroupResolver creates a NodeGroupResolver for the given node groups. machineSets and machines are optional
+// and only needed for resolution by ProviderID and for detecting unregistered machines.
+func NewNodeGroupResolver(nodeGroups map[string]NodeGroupInfo, machineSets []MachineSetInfo, machines []MachineInfo) *NodeGroupResolver {
+	r := &NodeGroupResolver{
+		nodeGroups:         nodeGroups,
+		groupsByPoolZone:   make(map[string]string, len(nodeGroups)),
+		groupsByProviderID: make(map[string]string, len(machines)),
+		machines:           machines,
+		groupsByMachineSet: make(map[string]string, len(machineSets)),
+	}
+	for name, ng := range nodeGroups {
+		r.groupsByPoolZone[poolZoneKey(ng.PoolName, ng.Zone)] = name
+	}
+	for _, ms := range machineSets {
+		if _, ok := nodeGroups[ms.MachineDeploymentName]; ok {
+			r.groupsByMachineSet[ms.Name] = ms.MachineDeploymentName
+		}
+	}
+	for _, m := range machines {
+		if ngName, ok := r.groupsByMachineSet[m.MachineSetName]; ok && m.ProviderID != "" {
+			r.groupsByProviderID[m.ProviderID] = ngName
+		}
+	}
+	return r
+}
+
+// Resolve returns the node group of the given node.
+func (r *NodeGroupResolver) Resolve(node NodeInfo) (ng NodeGroupInfo, ok bool) {
+	if ngName, found := r.groupsByProviderID[node.ProviderID]; found && node.ProviderID != "" {
+		ng, ok = r.nodeGroups[ngName]
+		return
+	}
+	poolName, found := GetPoolName(node.Labels)
+	if !found {
+		return
+	}
+	zone, found := GetZone(node.Labels)
+	if !found {
+		return
+	}
+	ngName, found := r.groupsByPoolZone[poolZoneKey(poolName, zone)]
+	if !found {
+		return
+	}
+	ng, ok = r.nodeGroups[ngName]
+	return
+}
+
+// Analyze resolves the given nodes, ignoring those being deleted, and reports nodes belonging to no group, groups
+// whose TargetSize differs from the number of registered nodes, and machines without a registered node that are
+// older than maxNodeProvisionTime at the given time.
+func (r *NodeGroupResolver) Analyze(nodes []NodeInfo, maxNodeProvisionTime time.Duration, now time.Time) (report NodeResolutionReport) {
+	report.NodesByGroup = make(map[string][]NodeInfo)
+	registered := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		if !node.DeletionTimestamp.IsZero() {
+			continue
+		}
+		registered[node.Name] = true
+		if node.ProviderID != "" {
+			registered[node.ProviderID] = true
+		}
+		ng, ok := r.Resolve(node)
+		if !ok {
+			report.OrphanedNodes = append(report.OrphanedNodes, node)
+			continue
+		}
+		report.NodesByGroup[ng.Name] = append(report.NodesByGroup[ng.Name], node)
+	}
+	for name, ng := range r.nodeGroups {
+		if count := len(report.NodesByGroup[name]); count != ng.TargetSize {
+			report.TargetSizeMismatches = append(report.TargetSizeMismatches, NodeGroupTargetSizeMismatch{NodeGroupName: name, TargetSize: ng.TargetSize, RegisteredNodes: count})
+		}
+	}
+	slices.SortFunc(report.TargetSizeMismatches, func(a, b NodeGroupTargetSizeMismatch) int {
+		return strings.Compare(a.NodeGroupName, b.NodeGroupName)
+	})
+	for _, m := range r.machines {
+		ngName, ok := r.groupsByMachineSet[m.MachineSetName]
+		if !ok || !m.DeletionTimestamp.IsZero() || registered[m.ProviderID] || registered[m.NodeName] {
+			continue
+		}
+		if age := now.Sub(m.CreationTimestamp); age > maxNodeProvisionTime {
+			report.UnregisteredMachines = append(report.UnregisteredMachines, UnregisteredMachine{NodeGroupName: ngName, Machine: m, Age: age})
+		}
+	}
+	return
+}
+
+func poolZoneKey(poolName, zone string) string {
+	return poolName + "|" + zone
+}
@@ -0,0 +1,126 @@
+package gsc
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNodeGroupResolverAnalyze(t *testing.T) {
+	now := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	nodeGroups := map[string]NodeGroupInfo{
+		"shoot--dev--aws-a-z1": {Name: "shoot--dev--aws-a-z1", PoolName: "a", Zone: "eu-west-1a", TargetSize: 2},
+		"shoot--dev--aws-a-z2": {Name: "shoot--dev--aws-a-z2", PoolName: "a", Zone: "eu-west-1b", TargetSize: 1},
+		"shoot--dev--aws-b-z1": {Name: "shoot--dev--aws-b-z1", PoolName: "b", Zone: "eu-west-1a", TargetSize: 0},
+	}
+	machineSets := []MachineSetInfo{
+		{SnapshotMeta: SnapshotMeta{Name: "shoot--dev--aws-a-z1-5b8c9"}, MachineDeploymentName: "shoot--dev--aws-a-z1"},
+		{SnapshotMeta: SnapshotMeta{Name: "shoot--dev--aws-a-z2-7d4f1"}, MachineDeploymentName: "shoot--dev--aws-a-z2"},
+		{SnapshotMeta: SnapshotMeta{Name: "shoot--dev--aws-c-z1-1a2b3"}, MachineDeploymentName: "shoot--dev--aws-c-z1"},
+	}
+	machine := func(name, machineSetName, providerID, nodeName string, age time.Duration) MachineInfo {
+		return MachineInfo{
+			SnapshotMeta:   SnapshotMeta{Name: name, CreationTimestamp: now.Add(-age)},
+			MachineSetName: machineSetName,
+			ProviderID:     providerID,
+			NodeName:       nodeName,
+		}
+	}
+	deletingMachine := machine("a-z2-deleting", "shoot--dev--aws-a-z2-7d4f1", "aws:///eu-west-1b/i-4", "", time.Hour)
+	deletingMachine.DeletionTimestamp = now
+	machines := []MachineInfo{
+		// registered by provider ID, node has labels of another zone
+		machine("a-z1-1", "shoot--dev--aws-a-z1-5b8c9", "aws:///eu-west-1a/i-1", "", time.Hour),
+		// registered by node name, provider ID not yet set on node
+		machine("a-z1-2", "shoot--dev--aws-a-z1-5b8c9", "aws:///eu-west-1a/i-2", "node-2", time.Hour),
+		// unregistered and older than max node provision time
+		machine("a-z2-1", "shoot--dev--aws-a-z2-7d4f1", "aws:///eu-west-1b/i-3", "", 30*time.Minute),
+		// unregistered but still within max node provision time
+		machine("a-z2-2", "shoot--dev--aws-a-z2-7d4f1", "", "", 10*time.Minute),
+		deletingMachine,
+		// machine set of unknown node group
+		machine("c-z1-1", "shoot--dev--aws-c-z1-1a2b3", "aws:///eu-west-1a/i-5", "", time.Hour),
+	}
+	node := func(name, providerID, poolName, zone string) NodeInfo {
+		labels := map[string]string{corev1.LabelTopologyZone: zone}
+		if poolName != "" {
+			labels[PoolLabel] = poolName
+		}
+		return NodeInfo{SnapshotMeta: SnapshotMeta{Name: name}, ProviderID: providerID, Labels: labels}
+	}
+	deletingNode := node("node-deleting", "", "a", "eu-west-1b")
+	deletingNode.DeletionTimestamp = now
+	nodes := []NodeInfo{
+		node("node-1", "aws:///eu-west-1a/i-1", "a", "eu-west-1b"),
+		node("node-2", "", "a", "eu-west-1a"),
+		node("node-3", "", "b", "eu-west-1a"),
+		node("node-orphan-pool", "", "x", "eu-west-1a"),
+		node("node-orphan-unlabeled", "", "", "eu-west-1a"),
+		deletingNode,
+	}
+
+	report := NewNodeGroupResolver(nodeGroups, machineSets, machines).Analyze(nodes, 20*time.Minute, now)
+
+	wantNodesByGroup := map[string][]string{
+		"shoot--dev--aws-a-z1": {"node-1", "node-2"},
+		"shoot--dev--aws-b-z1": {"node-3"},
+	}
+	gotNodesByGroup := make(map[string][]string, len(report.NodesByGroup))
+	for name, groupNodes := range report.NodesByGroup {
+		for _, n := range groupNodes {
+			gotNodesByGroup[name] = append(gotNodesByGroup[name], n.Name)
+		}
+	}
+	if !maps.EqualFunc(gotNodesByGroup, wantNodesByGroup, slices.Equal[[]string]) {
+		t.Errorf("expected nodes by group %v, got %v", wantNodesByGroup, gotNodesByGroup)
+	}
+
+	var orphans []string
+	for _, n := range report.OrphanedNodes {
+		orphans = append(orphans, n.Name)
+	}
+	if want := []string{"node-orphan-pool", "node-orphan-unlabeled"}; !slices.Equal(orphans, want) {
+		t.Errorf("expected orphaned nodes %v, got %v", want, orphans)
+	}
+
+	wantMismatches := []NodeGroupTargetSizeMismatch{
+		{NodeGroupName: "shoot--dev--aws-a-z2", TargetSize: 1, RegisteredNodes: 0},
+		{NodeGroupName: "shoot--dev--aws-b-z1", TargetSize: 0, RegisteredNodes: 1},
+	}
+	if !slices.Equal(report.TargetSizeMismatches, wantMismatches) {
+		t.Errorf("expected target size mismatches %v, got %v", wantMismatches, report.TargetSizeMismatches)
+	}
+
+	if len(report.UnregisteredMachines) != 1 {
+		t.Fatalf("expected one unregistered machine, got %v", report.UnregisteredMachines)
+	}
+	if u := report.UnregisteredMachines[0]; u.NodeGroupName != "shoot--dev--aws-a-z2" || u.Machine.Name != "a-z2-1" || u.Age != 30*time.Minute {
+		t.Errorf("expected machine a-z2-1 of node group shoot--dev--aws-a-z2 unregistered for 30m, got %s", u)
+	}
+}
+
+func TestNodeGroupResolverResolveByLabelsWithoutMachines(t *testing.T) {
+	resolver := NewNodeGroupResolver(map[string]NodeGroupInfo{
+		"shoot--dev--aws-a-z1": {Name: "shoot--dev--aws-a-z1", PoolName: "a", Zone: "eu-west-1a"},
+	}, nil, nil)
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{name: "pool and zone labels", labels: map[string]string{PoolLabel: "a", corev1.LabelTopologyZone: "eu-west-1a"}, want: true},
+		{name: "alternative pool and legacy zone labels", labels: map[string]string{PoolLabelAlt: "a", corev1.LabelFailureDomainBetaZone: "eu-west-1a"}, want: true},
+		{name: "missing zone label", labels: map[string]string{PoolLabel: "a"}},
+		{name: "unknown zone", labels: map[string]string{PoolLabel: "a", corev1.LabelTopologyZone: "eu-west-1b"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ng, ok := resolver.Resolve(NodeInfo{SnapshotMeta: SnapshotMeta{Name: "node"}, ProviderID: "aws:///eu-west-1a/i-1", Labels: tc.labels})
+			if ok != tc.want || (ok && ng.Name != "shoot--dev--aws-a-z1") {
+				t.Errorf("expected resolved %t, got %t with node group %q", tc.want, ok, ng.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package gsc
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"strings"
+	"time"
+)
+
+// Gardener defaults applied to unset worker pool and cluster-autoscaler fields of a Shoot spec.
+// See https://github.com/gardener/gardener/blob/master/docs/usage/autoscaling/shoot_autoscaling.md
+const (
+	DefaultArchitecture                  = "amd64"
+	DefaultExpander                      = "least-waste"
+	DefaultMaxNodeProvisionTime          = 20 * time.Minute
+	DefaultScanInterval                  = 10 * time.Second
+	DefaultMaxGracefulTerminationSeconds = 600
+	DefaultNewPodScaleUpDelay            = 0 * time.Second
+	DefaultMaxEmptyBulkDelete            = 10
+	DefaultIgnoreDaemonSetUtilization    = false
+)
+
+var DefaultMaxSurge = intstr.FromInt32(1)
+var DefaultMaxUnavailable = intstr.FromInt32(0)
+
+// ParseShootYAML parses the given Shoot YAML or JSON into an unstructured object map, preserving integers as int64.
+func ParseShootYAML(data []byte) (map[string]any, error) {
+	jsonData, err := utilyaml.ToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert shoot yaml to json: %w", err)
+	}
+	var shoot map[string]any
+	if err = utiljson.Unmarshal(jsonData, &shoot); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal shoot: %w", err)
+	}
+	return shoot, nil
+}
+
+// GetShootTechnicalID returns the status.technicalID of the given Shoot, which is the name of its control-plane
+// namespace. It falls back to shoot--<project>--<name> derived from the shoot namespace garden-<project>.
+func GetShootTechnicalID(shoot map[string]any) string {
+	obj := unstructured.Unstructured{Object: shoot}
+	if technicalID, _, _ := unstructured.NestedString(shoot, "status", "technicalID"); technicalID != "" {
+		return technicalID
+	}
+	return fmt.Sprintf("shoot--%s--%s", strings.TrimPrefix(obj.GetNamespace(), "garden-"), obj.GetName())
+}
+
+// ParseShootWorkerPools returns a WorkerPoolInfo captured at snapshotTime for every worker of spec.provider.workers
+// of the given Shoot, applying Gardener defaults for architecture, maxSurge and maxUnavailable.
+func ParseShootWorkerPools(shoot map[string]any, snapshotTime time.Time) ([]WorkerPoolInfo, error) {
+	obj := unstructured.Unstructured{Object: shoot}
+	workers, _, err := unstructured.NestedSlice(shoot, "spec", "provider", "workers")
+	if err != nil {
+		return nil, fmt.Errorf("cannot get workers of shoot %q: %w", obj.GetName(), err)
+	}
+	technicalID := GetShootTechnicalID(shoot)
+	poolInfos := make([]WorkerPoolInfo, 0, len(workers))
+	for _, w := range workers {
+		worker, ok := w.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("worker of shoot %q is not of type map[string]any", obj.GetName())
+		}
+		poolInfo, err := parseWorkerPool(worker)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse worker of shoot %q: %w", obj.GetName(), err)
+		}
+		poolInfo.SnapshotMeta.CreationTimestamp = obj.GetCreationTimestamp().UTC()
+		poolInfo.SnapshotMeta.SnapshotTimestamp = snapshotTime.UTC()
+		poolInfo.SnapshotMeta.Namespace = technicalID
+		if obj.GetDeletionTimestamp() != nil {
+			poolInfo.DeletionTimestamp = obj.GetDeletionTimestamp().UTC()
+		}
+		poolInfo.Hash = poolInfo.GetHash()
+		poolInfos = append(poolInfos, poolInfo)
+	}
+	return poolInfos, nil
+}
+
+func parseWorkerPool(worker map[string]any) (poolInfo WorkerPoolInfo, err error) {
+	poolInfo.Name, _, err = unstructured.NestedString(worker, "name")
+	if err != nil {
+		return
+	}
+	poolInfo.MachineType, _, err = unstructured.NestedString(worker, "machine", "type")
+	if err != nil {
+		return
+	}
+	poolInfo.Architecture, _, err = unstructured.NestedString(worker, "machine", "architecture")
+	if err != nil {
+		return
+	}
+	if poolInfo.Architecture == "" {
+		poolInfo.Architecture = DefaultArchitecture
+	}
+	minimum, _, err := unstructured.NestedInt64(worker, "minimum")
+	if err != nil {
+		return
+	}
+	maximum, _, err := unstructured.NestedInt64(worker, "maximum")
+	if err != nil {
+		return
+	}
+	poolInfo.Minimum, poolInfo.Maximum = int(minimum), int(maximum)
+	if poolInfo.MaxSurge, err = getIntOrStringOrDefault(worker, DefaultMaxSurge, "maxSurge"); err != nil {
+		return
+	}
+	if poolInfo.MaxUnavailable, err = getIntOrStringOrDefault(worker, DefaultMaxUnavailable, "maxUnavailable"); err != nil {
+		return
+	}
+	poolInfo.Zones, _, err = unstructured.NestedStringSlice(worker, "zones")
+	if err != nil {
+		return
+	}
+	poolInfo.Labels, _, err = unstructured.NestedStringMap(worker, "labels")
+	if err != nil {
+		return
+	}
+	taints, found, err := unstructured.NestedSlice(worker, "taints")
+	if err != nil || !found {
+		return
+	}
+	for _, t := range taints {
+		taintMap, ok := t.(map[string]any)
+		if !ok {
+			err = fmt.Errorf("taint of worker %q is not of type map[string]any", poolInfo.Name)
+			return
+		}
+		var taint corev1.Taint
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(taintMap, &taint); err != nil {
+			return
+		}
+		poolInfo.Taints = append(poolInfo.Taints, taint)
+	}
+	return
+}
+
+// ParseShootCASettings returns the CASettingsInfo captured at snapshotTime from spec.kubernetes.clusterAutoscaler of
+// the given Shoot, applying Gardener defaults for every unset field. NodeGroupsMinMax is filled with the per-zone
+// min and max of every worker pool, keyed by node group name.
+func ParseShootCASettings(shoot map[string]any, snapshotTime time.Time) (settings CASettingsInfo, err error) {
+	obj := unstructured.Unstructured{Object: shoot}
+	caSpec, _, err := unstructured.NestedMap(shoot, "spec", "kubernetes", "clusterAutoscaler")
+	if err != nil {
+		return settings, fmt.Errorf("cannot get clusterAutoscaler of shoot %q: %w", obj.GetName(), err)
+	}
+	settings = CASettingsInfo{
+		SnapshotTimestamp:             snapshotTime.UTC(),
+		Expander:                      DefaultExpander,
+		MaxNodeProvisionTime:          DefaultMaxNodeProvisionTime,
+		ScanInterval:                  DefaultScanInterval,
+		MaxGracefulTerminationSeconds: DefaultMaxGracefulTerminationSeconds,
+		NewPodScaleUpDelay:            DefaultNewPodScaleUpDelay,
+		MaxEmptyBulkDelete:            DefaultMaxEmptyBulkDelete,
+		IgnoreDaemonSetUtilization:    DefaultIgnoreDaemonSetUtilization,
+	}
+	if expander, _, _ := unstructured.NestedString(caSpec, "expander"); expander != "" {
+		settings.Expander = expander
+	}
+	for key, target := range map[string]*time.Duration{
+		"maxNodeProvisionTime": &settings.MaxNodeProvisionTime,
+		"scanInterval":         &settings.ScanInterval,
+		"newPodScaleUpDelay":   &settings.NewPodScaleUpDelay,
+	} {
+		durationStr, found, err := unstructured.NestedString(caSpec, key)
+		if err != nil {
+			return settings, fmt.Errorf("cannot get clusterAutoscaler.%s of shoot %q: %w", key, obj.GetName(), err)
+		}
+		if !found {
+			continue
+		}
+		if *target, err = time.ParseDuration(durationStr); err != nil {
+			return settings, fmt.Errorf("cannot parse clusterAutoscaler.%s of shoot %q: %w", key, obj.GetName(), err)
+		}
+	}
+	for key, target := range map[string]*int{
+		"maxGracefulTerminationSeconds": &settings.MaxGracefulTerminationSeconds,
+		"maxEmptyBulkDelete":            &settings.MaxEmptyBulkDelete,
+		"maxNodesTotal":                 &settings.MaxNodesTotal,
+	} {
+		val, found, err := unstructured.NestedInt64(caSpec, key)
+		if err != nil {
+			return settings, fmt.Errorf("cannot get clusterAutoscaler.%s of shoot %q: %w", key, obj.GetName(), err)
+		}
+		if found {
+			*target = int(val)
+		}
+	}
+	if ignore, found, _ := unstructured.NestedBool(caSpec, "ignoreDaemonsetsUtilization"); found {
+		settings.IgnoreDaemonSetUtilization = ignore
+	}
+
+	poolInfos, err := ParseShootWorkerPools(shoot, snapshotTime)
+	if err != nil {
+		return settings, err
+	}
+	settings.NodeGroupsMinMax = make(map[string]MinMax)
+	for _, p := range poolInfos {
+		for _, ng := range p.ToNodeGroups() {
+			settings.NodeGroupsMinMax[ng.Name] = MinMax{Min: ng.MinSize, Max: ng.MaxSize}
+		}
+	}
+	settings.Hash = settings.GetHash()
+	return
+}
+
+// GetNodeGroupName returns the name of the node group, which is also the name of the MCM MachineDeployment, of
+// the zone with the given index of a worker pool.
+func GetNodeGroupName(technicalID, poolName string, zoneIndex int) string {
+	return fmt.Sprintf("%s-%s-z%d", technicalID, poolName, zoneIndex+1)
+}
+
+// DistributeOverZones returns the part of size assigned to the zone with the given index when distributing size
+// over numZones zones the way Gardener does: every zone gets size/numZones and the remainder is handed out one by
+// one to the first zones.
+func DistributeOverZones(zoneIndex, size, numZones int) int {
+	if numZones <= 0 {
+		return 0
+	}
+	share := size / numZones
+	if zoneIndex < size%numZones {
+		share++
+	}
+	return share
+}
+
+func getIntOrStringOrDefault(obj map[string]any, defaultVal intstr.IntOrString, keys ...string) (intstr.IntOrString, error) {
+	val, err := GetInnerMapValue(obj, keys...)
+	if err != nil {
+		return defaultVal, nil
+	}
+	return AsIntOrString(val)
+}
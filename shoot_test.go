@@ -0,0 +1,182 @@
+package gsc
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const shootTestYAML = `apiVersion: core.gardener.cloud/v1beta1
+kind: Shoot
+metadata:
+  creationTimestamp: "2024-07-01T09:00:00Z"
+  name: aws
+  namespace: garden-dev
+spec:
+  kubernetes:
+    version: "1.29.4"
+%s  provider:
+    type: aws
+    workers:
+    - name: a
+      machine:
+        type: m5.large
+        image:
+          name: gardenlinux
+      minimum: 3
+      maximum: 7
+      zones:
+      - eu-west-1a
+      - eu-west-1b
+    - name: b
+      machine:
+        type: m5.xlarge
+        architecture: arm64
+      minimum: 0
+      maximum: 2
+      zones:
+      - eu-west-1c
+  region: eu-west-1
+status:
+  technicalID: shoot--dev--aws
+`
+
+func TestParseShootCASettings(t *testing.T) {
+	snapshotTime := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	wantMinMax := map[string]MinMax{
+		"shoot--dev--aws-a-z1": {Min: 2, Max: 4},
+		"shoot--dev--aws-a-z2": {Min: 1, Max: 3},
+		"shoot--dev--aws-b-z1": {Min: 0, Max: 2},
+	}
+	defaults := CASettingsInfo{
+		SnapshotTimestamp:             snapshotTime,
+		Expander:                      DefaultExpander,
+		MaxNodeProvisionTime:          DefaultMaxNodeProvisionTime,
+		ScanInterval:                  DefaultScanInterval,
+		MaxGracefulTerminationSeconds: DefaultMaxGracefulTerminationSeconds,
+		NewPodScaleUpDelay:            DefaultNewPodScaleUpDelay,
+		MaxEmptyBulkDelete:            DefaultMaxEmptyBulkDelete,
+		IgnoreDaemonSetUtilization:    DefaultIgnoreDaemonSetUtilization,
+	}
+	tests := []struct {
+		name              string
+		clusterAutoscaler string
+		want              func(s *CASettingsInfo)
+		wantErr           bool
+	}{
+		{
+			name: "defaults without cluster autoscaler section",
+		},
+		{
+			name: "defaults for empty cluster autoscaler section",
+			clusterAutoscaler: `    clusterAutoscaler: {}
+`,
+		},
+		{
+			name: "partial overrides keep remaining defaults",
+			clusterAutoscaler: `    clusterAutoscaler:
+      expander: priority
+      scanInterval: 30s
+      maxNodesTotal: 20
+`,
+			want: func(s *CASettingsInfo) {
+				s.Expander = "priority"
+				s.ScanInterval = 30 * time.Second
+				s.MaxNodesTotal = 20
+			},
+		},
+		{
+			name: "all overrides",
+			clusterAutoscaler: `    clusterAutoscaler:
+      expander: random
+      maxNodeProvisionTime: 15m
+      scanInterval: 1m
+      newPodScaleUpDelay: 5s
+      maxGracefulTerminationSeconds: 300
+      maxEmptyBulkDelete: 5
+      ignoreDaemonsetsUtilization: true
+`,
+			want: func(s *CASettingsInfo) {
+				s.Expander = "random"
+				s.MaxNodeProvisionTime = 15 * time.Minute
+				s.ScanInterval = time.Minute
+				s.NewPodScaleUpDelay = 5 * time.Second
+				s.MaxGracefulTerminationSeconds = 300
+				s.MaxEmptyBulkDelete = 5
+				s.IgnoreDaemonSetUtilization = true
+			},
+		},
+		{
+			name: "invalid duration",
+			clusterAutoscaler: `    clusterAutoscaler:
+      scanInterval: often
+`,
+			wantErr: true,
+		},
+		{
+			name: "integer of wrong type",
+			clusterAutoscaler: `    clusterAutoscaler:
+      maxEmptyBulkDelete: "5"
+`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			shoot, err := ParseShootYAML([]byte(fmt.Sprintf(shootTestYAML, tc.clusterAutoscaler)))
+			if err != nil {
+				t.Fatalf("cannot parse shoot: %v", err)
+			}
+			settings, err := ParseShootCASettings(shoot, snapshotTime)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("cannot parse cluster autoscaler settings: %v", err)
+			}
+			want := defaults
+			if tc.want != nil {
+				tc.want(&want)
+			}
+			if !maps.Equal(settings.NodeGroupsMinMax, wantMinMax) {
+				t.Errorf("expected node groups min max %v, got %v", wantMinMax, settings.NodeGroupsMinMax)
+			}
+			if settings.Hash == "" {
+				t.Error("expected hash to be set")
+			}
+			settings.NodeGroupsMinMax, settings.Hash = nil, ""
+			if !reflect.DeepEqual(settings, want) {
+				t.Errorf("expected settings %+v, got %+v", want, settings)
+			}
+		})
+	}
+}
+
+func TestParseShootWorkerPools(t *testing.T) {
+	shoot, err := ParseShootYAML([]byte(fmt.Sprintf(shootTestYAML, "")))
+	if err != nil {
+		t.Fatalf("cannot parse shoot: %v", err)
+	}
+	pools, err := ParseShootWorkerPools(shoot, time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("cannot parse worker pools: %v", err)
+	}
+	if len(pools) != 2 {
+		t.Fatalf("expected 2 worker pools, got %d", len(pools))
+	}
+	a, b := pools[0], pools[1]
+	if a.Namespace != "shoot--dev--aws" || a.MachineType != "m5.large" || a.Minimum != 3 || a.Maximum != 7 {
+		t.Errorf("unexpected worker pool a: %s", a)
+	}
+	if a.Architecture != DefaultArchitecture || b.Architecture != "arm64" {
+		t.Errorf("expected architectures %q and arm64, got %q and %q", DefaultArchitecture, a.Architecture, b.Architecture)
+	}
+	if a.MaxSurge != DefaultMaxSurge || a.MaxUnavailable != DefaultMaxUnavailable {
+		t.Errorf("expected default maxSurge %v and maxUnavailable %v, got %v and %v", DefaultMaxSurge, DefaultMaxUnavailable, a.MaxSurge, a.MaxUnavailable)
+	}
+}
@@ -0,0 +1,209 @@
+package signalutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	gsc "github.com/elankath/gardener-scaling-common"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultPollInterval is the interval at which WaitForSignal checks for signal files.
+const DefaultPollInterval = 500 * time.Millisecond
+
+var ErrHashMismatch = errors.New("signal hash does not match autoscaler config hash")
+var ErrSignalledError = errors.New("error signalled")
+var ErrNoSignalPath = errors.New("signal path not set")
+
+// Signal is the payload of a signal file exchanged between the replayer and the virtual autoscaler.
+type Signal struct {
+	// Hash is the AutoscalerConfig.Hash the signal refers to.
+	Hash      string    `json:"hash"`
+	Timestamp time.Time `json:"timestamp"`
+	// Error is the error text of an error signal.
+	Error string `json:"error,omitempty"`
+}
+
+func (s Signal) String() string {
+	return fmt.Sprintf("Signal(Hash=%s, Timestamp=%s, Error=%s)", s.Hash, s.Timestamp, s.Error)
+}
+
+// WriteSignal atomically writes the given signal to path by writing it to a temporary file in the same directory
+// and renaming it, so that a waiting reader never observes a partially written signal.
+func WriteSignal(path string, signal Signal) error {
+	if path == "" {
+		return ErrNoSignalPath
+	}
+	data, err := json.Marshal(signal)
+	if err != nil {
+		return fmt.Errorf("cannot marshal signal for %q: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory for signal %q: %w", path, err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary file for signal %q: %w", path, err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("cannot write signal %q: %w", path, err)
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("cannot sync signal %q: %w", path, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("cannot close signal %q: %w", path, err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cannot rename signal %q: %w", path, err)
+	}
+	return nil
+}
+
+// ReadSignal reads the signal at path. It returns an error satisfying errors.Is(err, fs.ErrNotExist) if there is none.
+func ReadSignal(path string) (signal Signal, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &signal); err != nil {
+		err = fmt.Errorf("cannot unmarshal signal %q: %w", path, err)
+	}
+	return
+}
+
+// SignalSuccess writes a success signal for the given config to its SuccessSignalPath.
+func SignalSuccess(config gsc.AutoscalerConfig) error {
+	return WriteSignal(config.SuccessSignalPath, Signal{Hash: config.Hash, Timestamp: time.Now().UTC()})
+}
+
+// SignalError writes an error signal carrying the text of the given error for the given config to its ErrorSignalPath.
+func SignalError(config gsc.AutoscalerConfig, signalErr error) error {
+	return WriteSignal(config.ErrorSignalPath, Signal{Hash: config.Hash, Timestamp: time.Now().UTC(), Error: signalErr.Error()})
+}
+
+// CleanSignals removes the success and error signal files of the given config, if present.
+func CleanSignals(config gsc.AutoscalerConfig) error {
+	var errs []error
+	for _, path := range []string{config.SuccessSignalPath, config.ErrorSignalPath} {
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("cannot remove signal %q: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CleanStaleSignals removes the signal files of the given config that do not match its Hash or that are older than
+// maxAge. A maxAge of zero or less only removes signals with a mismatched hash.
+func CleanStaleSignals(config gsc.AutoscalerConfig, maxAge time.Duration) error {
+	var errs []error
+	for _, path := range []string{config.SuccessSignalPath, config.ErrorSignalPath} {
+		if path == "" {
+			continue
+		}
+		signal, err := ReadSignal(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		stale := err != nil || signal.Hash != config.Hash || (maxAge > 0 && time.Since(signal.Timestamp) > maxAge)
+		if !stale {
+			continue
+		}
+		if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("cannot remove stale signal %q: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WaitForSignal waits until either the success or the error signal of the given config is present, the timeout
+// expires or the context is done, checking every pollInterval (DefaultPollInterval if zero or less). The signal
+// is consumed using claimSignal, so that concurrent waiters never consume the same signal twice. An error signal is
+// returned along with an error wrapping ErrSignalledError. Signals whose hash differs from config.Hash are left in
+// place for the config they belong to and waiting continues. If no matching signal arrives in time, the last
+// mismatched signal, if any, is returned along with an error wrapping both ErrHashMismatch and the context error.
+func WaitForSignal(ctx context.Context, config gsc.AutoscalerConfig, timeout time.Duration, pollInterval time.Duration) (Signal, error) {
+	if config.SuccessSignalPath == "" || config.ErrorSignalPath == "" {
+		return Signal{}, fmt.Errorf("cannot wait for signal of config %q: %w", config.Hash, ErrNoSignalPath)
+	}
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var mismatched Signal
+	var mismatchErr error
+	for {
+		for _, path := range []string{config.ErrorSignalPath, config.SuccessSignalPath} {
+			signal, err := claimSignal(path, config.Hash)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return signal, err
+			}
+			if signal.Hash != config.Hash {
+				mismatched, mismatchErr = signal, fmt.Errorf("cannot accept signal %q with hash %q for config hash %q: %w", path, signal.Hash, config.Hash, ErrHashMismatch)
+				continue
+			}
+			if path == config.ErrorSignalPath {
+				return signal, fmt.Errorf("%w: %s", ErrSignalledError, signal.Error)
+			}
+			return signal, nil
+		}
+		select {
+		case <-ctx.Done():
+			if mismatchErr != nil {
+				return mismatched, fmt.Errorf("stopped waiting for signal of config %q: %w: %w", config.Hash, ctx.Err(), mismatchErr)
+			}
+			return Signal{}, fmt.Errorf("stopped waiting for signal of config %q: %w", config.Hash, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimSignal consumes the signal at path if its hash equals the given hash. The signal file is first claimed by
+// atomically renaming it to a unique name in the same directory, so that only one caller can read it, and the
+// claimed copy is removed after reading. A signal that cannot be read or whose hash differs is put back at path
+// unless a newer signal has been written there meanwhile. It returns an error satisfying
+// errors.Is(err, fs.ErrNotExist) if there is no signal.
+func claimSignal(path, hash string) (signal Signal, err error) {
+	claimed, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".claimed.*")
+	if err != nil {
+		return signal, fmt.Errorf("cannot create claim file for signal %q: %w", path, err)
+	}
+	claimedPath := claimed.Name()
+	_ = claimed.Close()
+	defer func() {
+		_ = os.Remove(claimedPath)
+	}()
+	if err = os.Rename(path, claimedPath); err != nil {
+		return
+	}
+	signal, err = ReadSignal(claimedPath)
+	if err == nil && signal.Hash == hash {
+		return
+	}
+	if linkErr := os.Link(claimedPath, path); linkErr != nil && !errors.Is(linkErr, fs.ErrExist) {
+		err = errors.Join(err, fmt.Errorf("cannot restore signal %q: %w", path, linkErr))
+	}
+	return
+}
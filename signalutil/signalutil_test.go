@@ -0,0 +1,104 @@
+package signalutil
+
+import (
+	"context"
+	"errors"
+	gsc "github.com/elankath/gardener-scaling-common"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newSignalConfig(t *testing.T, hash string) gsc.AutoscalerConfig {
+	dir := t.TempDir()
+	return gsc.AutoscalerConfig{
+		Hash:              hash,
+		SuccessSignalPath: filepath.Join(dir, "success"),
+		ErrorSignalPath:   filepath.Join(dir, "error"),
+	}
+}
+
+func TestWaitForSignalSkipsMismatchedHash(t *testing.T) {
+	config := newSignalConfig(t, "current")
+	if err := WriteSignal(config.SuccessSignalPath, Signal{Hash: "stale", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = SignalSuccess(config)
+	}()
+
+	signal, err := WaitForSignal(context.Background(), config, 5*time.Second, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected matching signal, got %v", err)
+	}
+	if signal.Hash != config.Hash {
+		t.Errorf("expected signal with hash %q, got %s", config.Hash, signal)
+	}
+	if _, err = os.Stat(config.SuccessSignalPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected signal to be consumed, stat returned %v", err)
+	}
+}
+
+func TestWaitForSignalTimesOutWithMismatch(t *testing.T) {
+	config := newSignalConfig(t, "current")
+	if err := WriteSignal(config.ErrorSignalPath, Signal{Hash: "stale", Timestamp: time.Now(), Error: "boom"}); err != nil {
+		t.Fatal(err)
+	}
+
+	signal, err := WaitForSignal(context.Background(), config, 50*time.Millisecond, 10*time.Millisecond)
+	if !errors.Is(err, ErrHashMismatch) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected ErrHashMismatch and context.DeadlineExceeded, got %v", err)
+	}
+	if signal.Hash != "stale" {
+		t.Errorf("expected mismatched signal to be returned, got %s", signal)
+	}
+	if left, err := ReadSignal(config.ErrorSignalPath); err != nil || left.Hash != "stale" {
+		t.Errorf("expected mismatched signal to be left in place, got %s and %v", left, err)
+	}
+	assertNoClaimFiles(t, config)
+}
+
+func TestWaitForSignalConsumesSignalOnce(t *testing.T) {
+	config := newSignalConfig(t, "current")
+	results := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := WaitForSignal(context.Background(), config, 500*time.Millisecond, time.Millisecond)
+			results <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := SignalSuccess(config); err != nil {
+		t.Fatal(err)
+	}
+
+	var consumed, timedOut int
+	for range 2 {
+		err := <-results
+		switch {
+		case err == nil:
+			consumed++
+		case errors.Is(err, context.DeadlineExceeded):
+			timedOut++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if consumed != 1 || timedOut != 1 {
+		t.Errorf("expected signal to be consumed by exactly one waiter, got %d consumed and %d timed out", consumed, timedOut)
+	}
+	assertNoClaimFiles(t, config)
+}
+
+func assertNoClaimFiles(t *testing.T, config gsc.AutoscalerConfig) {
+	t.Helper()
+	claims, err := filepath.Glob(filepath.Join(filepath.Dir(config.SuccessSignalPath), ".*.claimed.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims) > 0 {
+		t.Errorf("expected claim files to be removed, got %v", claims)
+	}
+}
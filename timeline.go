@@ -0,0 +1,197 @@
+package gsc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const EventReasonScheduled = "Scheduled"
+
+type TimelineEntryType string
+
+const (
+	TimelinePodCreated          TimelineEntryType = "PodCreated"
+	TimelineSchedulingFailed    TimelineEntryType = "SchedulingFailed"
+	TimelineScaleUpTriggered    TimelineEntryType = "ScaleUpTriggered"
+	TimelineScaleUpNotTriggered TimelineEntryType = "ScaleUpNotTriggered"
+	TimelineScaleUpTimedOut     TimelineEntryType = "ScaleUpTimedOut"
+	TimelineNodeProvisioned     TimelineEntryType = "NodeProvisioned"
+	TimelinePodScheduled        TimelineEntryType = "PodScheduled"
+	TimelinePodDeleted          TimelineEntryType = "PodDeleted"
+)
+
+// TimelineEntry is a single step in the life of a pod.
+type TimelineEntry struct {
+	Time     time.Time
+	Type     TimelineEntryType
+	NodeName string
+	Message  string
+	// Event is the event the entry was derived from, if any.
+	Event *EventInfo
+}
+
+// PodTimeline lists the steps from creation to binding of a single pod in chronological order.
+type PodTimeline struct {
+	PodUID       string
+	PodName      string
+	PodNamespace string
+	Entries      []TimelineEntry
+}
+
+func (e TimelineEntry) String() string {
+	return fmt.Sprintf("%s %s NodeName=%s Message=%s", e.Time.Format(time.RFC3339), e.Type, e.NodeName, e.Message)
+}
+
+func (t PodTimeline) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("PodTimeline(UID=%s, Name=%s, Namespace=%s)", t.PodUID, t.PodName, t.PodNamespace))
+	for _, e := range t.Entries {
+		sb.WriteString("\n  ")
+		sb.WriteString(e.String())
+	}
+	return sb.String()
+}
+
+// GetSchedulingDuration returns the time between creation and scheduling of the pod, or false if the timeline
+// does not contain both.
+func (t PodTimeline) GetSchedulingDuration() (time.Duration, bool) {
+	created := slices.IndexFunc(t.Entries, func(e TimelineEntry) bool { return e.Type == TimelinePodCreated })
+	scheduled := slices.IndexFunc(t.Entries, func(e TimelineEntry) bool { return e.Type == TimelinePodScheduled })
+	if created < 0 || scheduled < 0 {
+		return 0, false
+	}
+	return t.Entries[scheduled].Time.Sub(t.Entries[created].Time), true
+}
+
+// BuildPodTimeline assembles the timeline of the pod with the given UID from the PodInfos and NodeInfos of the given
+// snapshot series and the events whose InvolvedObjectUID is the pod UID. ScaleUpTimedOut events are included
+// for node groups that were scaled up for the pod if they occurred after the scale-up was triggered and, for a
+// scheduled pod, before it got scheduled. Entries are sorted by time; entries derived from events
+// keep the order of gsc.CompareEventsByEventTime.
+func BuildPodTimeline(podUID string, snapshots []ClusterSnapshot, events []EventInfo) (timeline PodTimeline, err error) {
+	timeline.PodUID = podUID
+	var pod *PodInfo
+	var nodeName string
+	var scheduledAt time.Time
+	nodes := make(map[string]NodeInfo)
+	for _, s := range snapshots {
+		for _, n := range s.Nodes {
+			if _, ok := nodes[n.Name]; !ok {
+				nodes[n.Name] = n
+			}
+		}
+		for i := range s.Pods {
+			p := s.Pods[i]
+			if p.UID != podUID {
+				continue
+			}
+			if pod == nil {
+				pod = &p
+			}
+			if nodeName == "" && p.NodeName != "" {
+				nodeName, scheduledAt = p.NodeName, p.SnapshotTimestamp
+			}
+			if !p.DeletionTimestamp.IsZero() {
+				pod.DeletionTimestamp = p.DeletionTimestamp
+			}
+		}
+	}
+
+	podEvents := make([]EventInfo, 0)
+	for _, e := range events {
+		if e.InvolvedObjectUID == podUID {
+			podEvents = append(podEvents, e)
+		}
+	}
+	if pod == nil && len(podEvents) == 0 {
+		err = fmt.Errorf("cannot find pod with UID %q in snapshots or events", podUID)
+		return
+	}
+	slices.SortFunc(podEvents, CompareEventsByEventTime)
+
+	if pod != nil {
+		timeline.PodName, timeline.PodNamespace = pod.Name, pod.Namespace
+		timeline.Entries = append(timeline.Entries, TimelineEntry{Time: pod.CreationTimestamp, Type: TimelinePodCreated})
+	} else {
+		timeline.PodName, timeline.PodNamespace = podEvents[0].InvolvedObjectName, podEvents[0].InvolvedObjectNamespace
+	}
+
+	// scaledUpGroups holds the time a scale-up was first triggered for the pod per node group name.
+	scaledUpGroups := make(map[string]time.Time)
+	var scheduledByEvent bool
+	for i := range podEvents {
+		e := &podEvents[i]
+		entry := TimelineEntry{Time: e.EventTime, Message: e.Message, Event: e}
+		switch e.Reason {
+		case EventReasonFailedScheduling:
+			entry.Type = TimelineSchedulingFailed
+		case EventReasonNotTriggerScaleUp:
+			entry.Type = TimelineScaleUpNotTriggered
+		case EventReasonTriggeredScaleUp:
+			entry.Type = TimelineScaleUpTriggered
+			if parsed, parseErr := ParseTriggeredScaleUp(*e); parseErr == nil {
+				for _, su := range parsed.ScaleUps {
+					if _, ok := scaledUpGroups[su.NodeGroupName]; !ok {
+						scaledUpGroups[su.NodeGroupName] = e.EventTime
+					}
+				}
+			}
+		case EventReasonScheduled:
+			entry.Type = TimelinePodScheduled
+			if !scheduledByEvent {
+				scheduledAt = e.EventTime
+			}
+			scheduledByEvent = true
+			if nodeName == "" {
+				nodeName = scheduledNodeName(e.Message)
+			}
+			entry.NodeName = nodeName
+		default:
+			continue
+		}
+		timeline.Entries = append(timeline.Entries, entry)
+	}
+	for i := range events {
+		e := &events[i]
+		if e.Reason != EventReasonScaleUpTimedOut {
+			continue
+		}
+		parsed, parseErr := ParseScaleUpTimedOut(*e)
+		if parseErr != nil {
+			continue
+		}
+		triggeredAt, ok := scaledUpGroups[parsed.NodeGroupName]
+		if !ok || e.EventTime.Before(triggeredAt) || (!scheduledAt.IsZero() && !e.EventTime.Before(scheduledAt)) {
+			continue
+		}
+		timeline.Entries = append(timeline.Entries, TimelineEntry{Time: e.EventTime, Type: TimelineScaleUpTimedOut, Message: e.Message, Event: e})
+	}
+
+	if nodeName != "" {
+		// only nodes created after the pod are considered to have been provisioned for it.
+		if node, ok := nodes[nodeName]; ok && (pod == nil || node.CreationTimestamp.After(pod.CreationTimestamp)) {
+			timeline.Entries = append(timeline.Entries, TimelineEntry{Time: node.CreationTimestamp, Type: TimelineNodeProvisioned, NodeName: nodeName})
+		}
+		if !scheduledByEvent && !scheduledAt.IsZero() {
+			timeline.Entries = append(timeline.Entries, TimelineEntry{Time: scheduledAt, Type: TimelinePodScheduled, NodeName: nodeName, Message: "first observed bound in snapshot"})
+		}
+	}
+	if pod != nil && !pod.DeletionTimestamp.IsZero() {
+		timeline.Entries = append(timeline.Entries, TimelineEntry{Time: pod.DeletionTimestamp, Type: TimelinePodDeleted})
+	}
+	slices.SortStableFunc(timeline.Entries, func(a, b TimelineEntry) int {
+		return a.Time.Compare(b.Time)
+	})
+	return
+}
+
+// scheduledNodeName extracts the node name from a scheduler message like "Successfully assigned ns/pod to node-1".
+func scheduledNodeName(message string) string {
+	_, nodeName, found := strings.Cut(message, " to ")
+	if !found {
+		return ""
+	}
+	return strings.TrimSpace(nodeName)
+}
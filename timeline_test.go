@@ -0,0 +1,159 @@
+package gsc
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+type timelineTestEntry struct {
+	Offset   time.Duration
+	Type     TimelineEntryType
+	NodeName string
+}
+
+var timelineTestStart = time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+
+func newTimelineTestEvent(offset time.Duration, uid, reason, message string) EventInfo {
+	return EventInfo{
+		UID:                     reason + "-" + offset.String(),
+		EventTime:               timelineTestStart.Add(offset),
+		Reason:                  reason,
+		Message:                 message,
+		InvolvedObjectKind:      "Pod",
+		InvolvedObjectName:      "web",
+		InvolvedObjectNamespace: "shop",
+		InvolvedObjectUID:       uid,
+	}
+}
+
+// newTimelineTestSnapshots returns snapshots at 1m and 5m after timelineTestStart. The pod created at the start is
+// unscheduled in the first and bound to nodeName in the second. Node "old" predates the pod, node "new" is created
+// 3m after it.
+func newTimelineTestSnapshots(nodeName string) []ClusterSnapshot {
+	nodes := []NodeInfo{
+		{SnapshotMeta: SnapshotMeta{Name: "old", CreationTimestamp: timelineTestStart.Add(-time.Hour)}},
+		{SnapshotMeta: SnapshotMeta{Name: "new", CreationTimestamp: timelineTestStart.Add(3 * time.Minute)}},
+	}
+	pod := func(snapshotTime time.Time, nodeName string) PodInfo {
+		return PodInfo{
+			SnapshotMeta: SnapshotMeta{Name: "web", Namespace: "shop", CreationTimestamp: timelineTestStart, SnapshotTimestamp: snapshotTime},
+			UID:          "uid-web",
+			NodeName:     nodeName,
+		}
+	}
+	first, second := timelineTestStart.Add(time.Minute), timelineTestStart.Add(5*time.Minute)
+	return []ClusterSnapshot{
+		{SnapshotTime: first, Nodes: nodes[:1], Pods: []PodInfo{pod(first, "")}},
+		{SnapshotTime: second, Nodes: nodes, Pods: []PodInfo{pod(second, nodeName)}},
+	}
+}
+
+func TestBuildPodTimeline(t *testing.T) {
+	triggered := newTimelineTestEvent(20*time.Second, "uid-web", EventReasonTriggeredScaleUp, "pod triggered scale-up: [{ng-a 1->2 (max: 5)}]")
+	tests := []struct {
+		name      string
+		snapshots []ClusterSnapshot
+		events    []EventInfo
+		want      []timelineTestEntry
+	}{
+		{
+			name:      "scale-up onto provisioned node",
+			snapshots: newTimelineTestSnapshots("new"),
+			events: []EventInfo{
+				newTimelineTestEvent(2*time.Minute, "uid-web", EventReasonFailedScheduling, "0/1 nodes are available: 1 Insufficient cpu."),
+				triggered,
+				newTimelineTestEvent(10*time.Second, "uid-web", EventReasonFailedScheduling, "0/1 nodes are available: 1 Insufficient cpu."),
+				newTimelineTestEvent(30*time.Second, "uid-other", EventReasonFailedScheduling, "0/1 nodes are available: 1 Insufficient cpu."),
+			},
+			want: []timelineTestEntry{
+				{Type: TimelinePodCreated},
+				{Offset: 10 * time.Second, Type: TimelineSchedulingFailed},
+				{Offset: 20 * time.Second, Type: TimelineScaleUpTriggered},
+				{Offset: 2 * time.Minute, Type: TimelineSchedulingFailed},
+				{Offset: 3 * time.Minute, Type: TimelineNodeProvisioned, NodeName: "new"},
+				{Offset: 5 * time.Minute, Type: TimelinePodScheduled, NodeName: "new"},
+			},
+		},
+		{
+			name:      "scale-up timeouts between trigger and schedule time of scaled up node group",
+			snapshots: newTimelineTestSnapshots("new"),
+			events: []EventInfo{
+				triggered,
+				newTimelineTestEvent(15*time.Second, "", EventReasonScaleUpTimedOut, "Nodes added to group ng-a failed to register within 15m0s"),
+				newTimelineTestEvent(2*time.Minute, "", EventReasonScaleUpTimedOut, "Nodes added to group ng-b failed to register within 15m0s"),
+				newTimelineTestEvent(2*time.Minute, "", EventReasonScaleUpTimedOut, "Nodes added to group ng-a failed to register within 15m0s"),
+				newTimelineTestEvent(5*time.Minute, "", EventReasonScaleUpTimedOut, "Nodes added to group ng-a failed to register within 15m0s"),
+			},
+			want: []timelineTestEntry{
+				{Type: TimelinePodCreated},
+				{Offset: 20 * time.Second, Type: TimelineScaleUpTriggered},
+				{Offset: 2 * time.Minute, Type: TimelineScaleUpTimedOut},
+				{Offset: 3 * time.Minute, Type: TimelineNodeProvisioned, NodeName: "new"},
+				{Offset: 5 * time.Minute, Type: TimelinePodScheduled, NodeName: "new"},
+			},
+		},
+		{
+			name:      "scheduled event takes precedence over snapshot and bounds scale-up timeouts",
+			snapshots: newTimelineTestSnapshots("new"),
+			events: []EventInfo{
+				triggered,
+				newTimelineTestEvent(4*time.Minute, "uid-web", EventReasonScheduled, "Successfully assigned shop/web to new"),
+				newTimelineTestEvent(4*time.Minute+30*time.Second, "", EventReasonScaleUpTimedOut, "Nodes added to group ng-a failed to register within 15m0s"),
+			},
+			want: []timelineTestEntry{
+				{Type: TimelinePodCreated},
+				{Offset: 20 * time.Second, Type: TimelineScaleUpTriggered},
+				{Offset: 3 * time.Minute, Type: TimelineNodeProvisioned, NodeName: "new"},
+				{Offset: 4 * time.Minute, Type: TimelinePodScheduled, NodeName: "new"},
+			},
+		},
+		{
+			name:      "node created before pod is not provisioned for it",
+			snapshots: newTimelineTestSnapshots("old"),
+			want: []timelineTestEntry{
+				{Type: TimelinePodCreated},
+				{Offset: 5 * time.Minute, Type: TimelinePodScheduled, NodeName: "old"},
+			},
+		},
+		{
+			name:      "pod only known from events",
+			snapshots: []ClusterSnapshot{{Nodes: newTimelineTestSnapshots("")[1].Nodes}},
+			events: []EventInfo{
+				newTimelineTestEvent(10*time.Second, "uid-web", EventReasonFailedScheduling, "0/1 nodes are available: 1 Insufficient cpu."),
+				triggered,
+				newTimelineTestEvent(4*time.Minute, "uid-web", EventReasonScheduled, "Successfully assigned shop/web to new"),
+			},
+			want: []timelineTestEntry{
+				{Offset: 10 * time.Second, Type: TimelineSchedulingFailed},
+				{Offset: 20 * time.Second, Type: TimelineScaleUpTriggered},
+				{Offset: 3 * time.Minute, Type: TimelineNodeProvisioned, NodeName: "new"},
+				{Offset: 4 * time.Minute, Type: TimelinePodScheduled, NodeName: "new"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			timeline, err := BuildPodTimeline("uid-web", tc.snapshots, tc.events)
+			if err != nil {
+				t.Fatalf("cannot build pod timeline: %v", err)
+			}
+			if timeline.PodName != "web" || timeline.PodNamespace != "shop" {
+				t.Errorf("expected timeline of pod shop/web, got %s/%s", timeline.PodNamespace, timeline.PodName)
+			}
+			got := make([]timelineTestEntry, 0, len(timeline.Entries))
+			for _, e := range timeline.Entries {
+				got = append(got, timelineTestEntry{Offset: e.Time.Sub(timelineTestStart), Type: e.Type, NodeName: e.NodeName})
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expected entries %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBuildPodTimelineUnknownPod(t *testing.T) {
+	if _, err := BuildPodTimeline("uid-missing", newTimelineTestSnapshots("new"), nil); err == nil {
+		t.Fatal("expected error for pod missing from snapshots and events")
+	}
+}
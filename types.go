@@ -3,6 +3,7 @@ package gsc
 import (
 	corev1 "k8s.io/api/core/v1"
 	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"time"
 )
@@ -103,6 +104,53 @@ type MachineDeploymentInfo struct {
 	Hash              string
 }
 
+// MachineOperationInfo represents the last operation performed by MCM on a Machine or MachineSet.
+type MachineOperationInfo struct {
+	// Type is the operation type such as Create, Delete, HealthCheck.
+	Type string
+	// State is the operation state such as Processing, Successful, Failed.
+	State          string
+	Description    string
+	ErrorCode      string
+	LastUpdateTime time.Time
+}
+
+// MachineSetInfo represents snapshot information captured about the MCM MachineSet object
+// present in the control plane of a gardener shoot cluster.
+type MachineSetInfo struct {
+	SnapshotMeta
+	MachineDeploymentName string
+	Replicas              int
+	ReadyReplicas         int
+	AvailableReplicas     int
+	MachineClassName      string
+	LastOperation         MachineOperationInfo
+	// FailedMachineNames are the names of the machines of the set whose last operation failed.
+	FailedMachineNames []string
+	Labels             map[string]string
+	DeletionTimestamp  time.Time
+	Hash               string
+}
+
+// MachineInfo represents snapshot information captured about the MCM Machine object
+// present in the control plane of a gardener shoot cluster.
+type MachineInfo struct {
+	SnapshotMeta
+	MachineSetName   string
+	MachineClassName string
+	ProviderID       string
+	NodeName         string
+	// Phase is the current phase of the machine such as Pending, Running, Failed, Terminating.
+	Phase         string
+	LastOperation MachineOperationInfo
+	// ErrorDescription is the description of the last operation if it failed, for instance due to quota exceeded or
+	// out of capacity errors of the provider.
+	ErrorDescription  string
+	Labels            map[string]string
+	DeletionTimestamp time.Time
+	Hash              string
+}
+
 type MinMax struct {
 	Min int
 	Max int
@@ -123,6 +171,10 @@ type CASettingsInfo struct {
 	MaxEmptyBulkDelete            int
 	IgnoreDaemonSetUtilization    bool
 	MaxNodesTotal                 int `db:"MaxNodesTotal"`
+	// MaxCoresTotal is the maximum number of cores in the cluster. Zero means no limit.
+	MaxCoresTotal int
+	// MaxMemoryTotal is the maximum amount of memory in the cluster. Zero means no limit.
+	MaxMemoryTotal resource.Quantity
 	// Priorities is the value of the `priorities` key in the `cluster-autoscaler-priority-expander` config map.
 	// See https://github.com/kubernetes/autoscaler/blob/master/cluster-autoscaler/expander/priority/readme.md#configuration
 	Priorities string
@@ -185,6 +237,16 @@ type EventInfo struct {
 	InvolvedObjectName      string    `db:"InvolvedObjectName"`
 	InvolvedObjectNamespace string    `db:"InvolvedObjectNamespace"`
 	InvolvedObjectUID       string    `db:"InvolvedObjectUID"`
+	// Type is either Normal or Warning.
+	Type string `db:"Type"`
+	// Action is the action taken or failed regarding the involved object.
+	Action         string    `db:"Action"`
+	Count          int32     `db:"Count"`
+	FirstTimestamp time.Time `db:"FirstTimestamp"`
+	LastTimestamp  time.Time `db:"LastTimestamp"`
+	// SeriesCount and SeriesLastObservedTime are only set for events that are part of an event series.
+	SeriesCount            int32     `db:"SeriesCount"`
+	SeriesLastObservedTime time.Time `db:"SeriesLastObservedTime"`
 }
 
 // ClusterSnapshot represents captured snapshot information about a gardener cluster that is useful for auto-scaling state.
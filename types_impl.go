@@ -24,7 +24,7 @@ func (m MinMax) String() string {
 
 func (w WorkerPoolInfo) String() string {
 	metaStr := header("WorkerPoolInfo", w.SnapshotMeta)
-	return fmt.Sprintf("%s, MachineType=%d, Architecture=%s, Minimum=%d, Maximum=%d, MaxSurge=%s, MaxUnavailable=%s,  Zones=%s, Labels=%s,Taints=%s, Hash=%s)",
+	return fmt.Sprintf("%s, MachineType=%s, Architecture=%s, Minimum=%d, Maximum=%d, MaxSurge=%s, MaxUnavailable=%s,  Zones=%s, Labels=%s,Taints=%s, Hash=%s)",
 		metaStr, w.MachineType, w.Architecture, w.Minimum, w.Maximum, w.MaxSurge.String(), w.MaxUnavailable.String(), w.Zones, w.Labels, w.Taints, w.Hash)
 }
 
@@ -148,6 +148,59 @@ func (m MachineDeploymentInfo) GetHash() string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
+func (o MachineOperationInfo) String() string {
+	return fmt.Sprintf("(Type=%s, State=%s, Description=%s, ErrorCode=%s, LastUpdateTime=%s)", o.Type, o.State, o.Description, o.ErrorCode, o.LastUpdateTime)
+}
+
+func hashMachineOperation(hasher hash.Hash, o MachineOperationInfo) {
+	hasher.Write([]byte(o.Type))
+	hasher.Write([]byte(o.State))
+	hasher.Write([]byte(o.Description))
+	hasher.Write([]byte(o.ErrorCode))
+}
+
+func (m MachineSetInfo) String() string {
+	metaStr := header("MachineSetInfo", m.SnapshotMeta)
+	return fmt.Sprintf("%s, MachineDeploymentName=%s, Replicas=%d, ReadyReplicas=%d, AvailableReplicas=%d, MachineClassName=%s, LastOperation=%s, FailedMachineNames=%s, Labels=%s, Hash=%s)",
+		metaStr, m.MachineDeploymentName, m.Replicas, m.ReadyReplicas, m.AvailableReplicas, m.MachineClassName, m.LastOperation, m.FailedMachineNames, m.Labels, m.Hash)
+}
+
+func (m MachineSetInfo) GetHash() string {
+	hasher := md5.New()
+	hasher.Write([]byte(m.Name))
+	hasher.Write([]byte(m.Namespace))
+	hasher.Write([]byte(m.MachineDeploymentName))
+	HashInt(hasher, m.Replicas)
+	HashInt(hasher, m.ReadyReplicas)
+	HashInt(hasher, m.AvailableReplicas)
+	hasher.Write([]byte(m.MachineClassName))
+	hashMachineOperation(hasher, m.LastOperation)
+	HashSlice(hasher, m.FailedMachineNames)
+	HashLabels(hasher, m.Labels)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+func (m MachineInfo) String() string {
+	metaStr := header("MachineInfo", m.SnapshotMeta)
+	return fmt.Sprintf("%s, MachineSetName=%s, MachineClassName=%s, ProviderID=%s, NodeName=%s, Phase=%s, LastOperation=%s, ErrorDescription=%s, Labels=%s, Hash=%s)",
+		metaStr, m.MachineSetName, m.MachineClassName, m.ProviderID, m.NodeName, m.Phase, m.LastOperation, m.ErrorDescription, m.Labels, m.Hash)
+}
+
+func (m MachineInfo) GetHash() string {
+	hasher := md5.New()
+	hasher.Write([]byte(m.Name))
+	hasher.Write([]byte(m.Namespace))
+	hasher.Write([]byte(m.MachineSetName))
+	hasher.Write([]byte(m.MachineClassName))
+	hasher.Write([]byte(m.ProviderID))
+	hasher.Write([]byte(m.NodeName))
+	hasher.Write([]byte(m.Phase))
+	hashMachineOperation(hasher, m.LastOperation)
+	hasher.Write([]byte(m.ErrorDescription))
+	HashLabels(hasher, m.Labels)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
 func (n NodeInfo) String() string {
 	return fmt.Sprintf(
 		"NodeInfo(Name=%s, Namespace=%s, CreationTimestamp=%s, ProviderID=%s, Labels=%s, Taints=%s, Allocatable=%s, Capacity=%s)",
@@ -264,8 +317,12 @@ func (p PodInfo) GetHash() string {
 }
 
 func (p PriorityClassInfo) String() string {
+	var preemptionPolicy corev1.PreemptionPolicy
+	if p.PreemptionPolicy != nil {
+		preemptionPolicy = *p.PreemptionPolicy
+	}
 	return fmt.Sprintf("PriorityClassInfo(RowID=%d,  CreationTimestamp=%s, SnapshotTimestamp=%s, Name=%s, Value=%d, PreemptionPolicy=%s, GlobalDefault=%t)",
-		p.RowID, p.CreationTimestamp, p.SnapshotTimestamp, p.Name, p.Value, *p.PreemptionPolicy, p.GlobalDefault)
+		p.RowID, p.CreationTimestamp, p.SnapshotTimestamp, p.Name, p.Value, preemptionPolicy, p.GlobalDefault)
 }
 
 func (p PriorityClassInfo) GetHash() string {
@@ -377,8 +434,8 @@ func ResourcesAsString(resources corev1.ResourceList) string {
 }
 
 func (eI EventInfo) String() string {
-	return fmt.Sprintf("EventInfo : (UID = %s,EventTime = %s, ReportingController = %s, Reason = %s, Message = %s, InvolvedObjectName = %s,InvolvedObjectNamespace = %s, InvolvedObjectUID = %s)",
-		eI.UID, eI.EventTime, eI.ReportingController, eI.Reason, eI.Message, eI.InvolvedObjectName, eI.InvolvedObjectNamespace, eI.InvolvedObjectUID)
+	return fmt.Sprintf("EventInfo : (UID = %s,EventTime = %s, ReportingController = %s, Type = %s, Reason = %s, Action = %s, Message = %s, InvolvedObjectName = %s,InvolvedObjectNamespace = %s, InvolvedObjectUID = %s, Count = %d, FirstTimestamp = %s, LastTimestamp = %s, SeriesCount = %d, SeriesLastObservedTime = %s)",
+		eI.UID, eI.EventTime, eI.ReportingController, eI.Type, eI.Reason, eI.Action, eI.Message, eI.InvolvedObjectName, eI.InvolvedObjectNamespace, eI.InvolvedObjectUID, eI.Count, eI.FirstTimestamp, eI.LastTimestamp, eI.SeriesCount, eI.SeriesLastObservedTime)
 }
 
 func IsResourceListEqual(r1 corev1.ResourceList, r2 corev1.ResourceList) bool {
@@ -407,12 +464,19 @@ func (cas CASettingsInfo) GetHash() string {
 	HashBool(hasher, cas.IgnoreDaemonSetUtilization)
 	HashInt(hasher, cas.MaxNodesTotal)
 	hasher.Write([]byte(cas.Priorities))
+	// only hash resource limits when set so that hashes of previously recorded settings remain stable.
+	if cas.MaxCoresTotal > 0 {
+		HashInt(hasher, cas.MaxCoresTotal)
+	}
+	if !cas.MaxMemoryTotal.IsZero() {
+		HashResource(hasher, corev1.ResourceMemory, cas.MaxMemoryTotal)
+	}
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
 func (cas CASettingsInfo) String() string {
-	return fmt.Sprintf("(SnapshotTime=%s, Expander=%s, NodeGroupsMinMax=%v, MaxNodeProvisionTime=%s, ScanInterval=%s, MaxGracefulTerminationSeconds=%d, NewPodScaleUpDelay=%d, MaxNodesTotal=%d, Priorities=%s, Hash=%s)",
-		cas.SnapshotTimestamp, cas.Expander, cas.NodeGroupsMinMax, cas.MaxNodeProvisionTime, cas.ScanInterval, cas.MaxGracefulTerminationSeconds, cas.NewPodScaleUpDelay, cas.MaxNodesTotal, cas.Priorities, cas.Hash)
+	return fmt.Sprintf("(SnapshotTime=%s, Expander=%s, NodeGroupsMinMax=%v, MaxNodeProvisionTime=%s, ScanInterval=%s, MaxGracefulTerminationSeconds=%d, NewPodScaleUpDelay=%d, MaxNodesTotal=%d, MaxCoresTotal=%d, MaxMemoryTotal=%s, Priorities=%s, Hash=%s)",
+		cas.SnapshotTimestamp, cas.Expander, cas.NodeGroupsMinMax, cas.MaxNodeProvisionTime, cas.ScanInterval, cas.MaxGracefulTerminationSeconds, cas.NewPodScaleUpDelay, cas.MaxNodesTotal, cas.MaxCoresTotal, cas.MaxMemoryTotal.String(), cas.Priorities, cas.Hash)
 }
 
 func SumResources(resources []corev1.ResourceList) corev1.ResourceList {
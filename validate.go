@@ -0,0 +1,108 @@
+package gsc
+
+import (
+	"errors"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var ErrInvalidSnapshot = errors.New("invalid cluster snapshot")
+
+// Validate checks the AutoscalerConfig for consistency: every node group must have a NodeTemplate and sizes with
+// MinSize <= TargetSize <= MaxSize, and set hashes must match the computed ones. All problems found are joined
+// into the returned error.
+func (a AutoscalerConfig) Validate() error {
+	var errs []error
+	for name, ng := range a.NodeGroups {
+		if ng.Name != name {
+			errs = append(errs, fmt.Errorf("node group key %q does not match its name %q", name, ng.Name))
+		}
+		if _, ok := a.NodeTemplates[name]; !ok {
+			errs = append(errs, fmt.Errorf("node group %q has no node template", name))
+		}
+		if ng.MinSize < 0 || ng.MinSize > ng.TargetSize || ng.TargetSize > ng.MaxSize {
+			errs = append(errs, fmt.Errorf("node group %q violates 0 <= MinSize(%d) <= TargetSize(%d) <= MaxSize(%d)", name, ng.MinSize, ng.TargetSize, ng.MaxSize))
+		}
+		if ng.Hash != "" && ng.Hash != ng.GetHash() {
+			errs = append(errs, fmt.Errorf("node group %q has stale hash", name))
+		}
+	}
+	for name, t := range a.NodeTemplates {
+		if t.Hash != "" && t.Hash != t.GetHash() {
+			errs = append(errs, fmt.Errorf("node template %q has stale hash", name))
+		}
+	}
+	if a.Hash != "" && a.Hash != a.GetHash() {
+		errs = append(errs, errors.New("autoscaler config has stale hash"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the snapshot for consistency: its AutoscalerConfig must be valid, node names and pod UIDs must be
+// unique, pods bound to a node must reference a node of the snapshot that has enough allocatable resources for all
+// its pods, unscheduled pods must not be bound, referenced priority classes must exist and set hashes must match
+// the computed ones. All problems found are joined into the returned error wrapping ErrInvalidSnapshot.
+func (c ClusterSnapshot) Validate() error {
+	var errs []error
+	if err := c.AutoscalerConfig.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	nodesByName := make(map[string]NodeInfo, len(c.Nodes))
+	for _, n := range c.Nodes {
+		if _, ok := nodesByName[n.Name]; ok {
+			errs = append(errs, fmt.Errorf("duplicate node %q", n.Name))
+		}
+		nodesByName[n.Name] = n
+		if n.Hash != n.GetHash() {
+			errs = append(errs, fmt.Errorf("node %q has stale hash", n.Name))
+		}
+	}
+	priorityClassNames := make(map[string]bool, len(c.PriorityClasses))
+	for _, pc := range c.PriorityClasses {
+		priorityClassNames[pc.Name] = true
+		if pc.Hash != pc.GetHash() {
+			errs = append(errs, fmt.Errorf("priority class %q has stale hash", pc.Name))
+		}
+	}
+	podUIDs := make(map[string]bool, len(c.Pods))
+	requestsByNode := make(map[string][]corev1.ResourceList)
+	for _, p := range c.Pods {
+		podName := p.Namespace + "/" + p.Name
+		if podUIDs[p.UID] {
+			errs = append(errs, fmt.Errorf("duplicate pod UID %q of pod %q", p.UID, podName))
+		}
+		podUIDs[p.UID] = true
+		if p.Hash != p.GetHash() {
+			errs = append(errs, fmt.Errorf("pod %q has stale hash", podName))
+		}
+		if p.Spec.PriorityClassName != "" && !priorityClassNames[p.Spec.PriorityClassName] {
+			errs = append(errs, fmt.Errorf("pod %q references unknown priority class %q", podName, p.Spec.PriorityClassName))
+		}
+		if p.NodeName == "" {
+			continue
+		}
+		if p.PodScheduleStatus == PodUnscheduled {
+			errs = append(errs, fmt.Errorf("unscheduled pod %q is bound to node %q", podName, p.NodeName))
+		}
+		if _, ok := nodesByName[p.NodeName]; !ok {
+			errs = append(errs, fmt.Errorf("pod %q is bound to unknown node %q", podName, p.NodeName))
+			continue
+		}
+		requestsByNode[p.NodeName] = append(requestsByNode[p.NodeName], p.Requests)
+	}
+	for nodeName, requests := range requestsByNode {
+		allocatable := nodesByName[nodeName].Allocatable
+		for resourceName, requested := range SumResources(requests) {
+			if available, ok := allocatable[resourceName]; ok && requested.Cmp(available) > 0 {
+				errs = append(errs, fmt.Errorf("pods on node %q request %s of %s exceeding allocatable %s", nodeName, requested.String(), resourceName, available.String()))
+			}
+		}
+	}
+	if c.Hash != "" && c.Hash != c.GetHash() {
+		errs = append(errs, errors.New("snapshot has stale hash"))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w %q: %w", ErrInvalidSnapshot, c.ID, errors.Join(errs...))
+}